@@ -1,55 +1,25 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
 	"strings"
+
+	"github.com/wealthystudent/fuzzyrepo/index"
 )
 
 var (
 	ErrNoEditor      = errors.New("$EDITOR is not set")
 	ErrInvalidEditor = errors.New("$EDITOR contains invalid characters")
-	ErrCloneFailed   = errors.New("git clone failed")
-	ErrAlreadyExists = errors.New("local path already exists")
+	ErrCloneFailed   = index.ErrCloneFailed
+	ErrAlreadyExists = index.ErrAlreadyExists
 	ErrInvalidPath   = errors.New("path contains invalid characters")
 )
 
-func CloneRepo(repo Repository, config Config) (string, error) {
-	if repo.ExistsLocal && repo.LocalPath != "" {
-		return repo.LocalPath, ErrAlreadyExists
-	}
-
-	destPath := config.GetClonePath(repo.FullName, repo.Name)
-	destDir := filepath.Dir(destPath)
-
-	if _, err := os.Stat(destPath); err == nil {
-		return destPath, ErrAlreadyExists
-	}
-
-	if err := os.MkdirAll(destDir, 0o755); err != nil {
-		return "", fmt.Errorf("create clone directory: %w", err)
-	}
-
-	cloneURL := repo.SSHURL
-	if cloneURL == "" {
-		cloneURL = fmt.Sprintf("git@github.com:%s/%s.git", repo.Owner, repo.Name)
-	}
-
-	cmd := exec.Command("git", "clone", cloneURL, destPath)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("%w: %v", ErrCloneFailed, err)
-	}
-
-	return destPath, nil
-}
-
 // isValidEditor checks if the editor value is safe to execute.
 // Only allows simple command names or absolute paths, no shell metacharacters.
 func isValidEditor(editor string) bool {
@@ -67,22 +37,27 @@ func isValidEditor(editor string) bool {
 	return true
 }
 
-func OpenInEditor(path, repoName string) error {
-	nvimAddr := os.Getenv("NVIM")
-	if nvimAddr != "" {
-		return openInNeovim(path, repoName, nvimAddr)
-	}
+// OpenInEditor opens path with the editor configured for repo. A per-repo
+// editor override always runs; otherwise an editor integration is used (the
+// configured one, or the editor fuzzyrepo runs inside of), then the editor
+// setting, then $EDITOR.
+func OpenInEditor(path string, repo Repository, config Config) error {
+	argv, override := config.GetEditor(repo)
 
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		return ErrNoEditor
+	if !override {
+		if integration, ok := findIntegration(config); ok {
+			return integration.open(path, repo, config)
+		}
 	}
 
-	if !isValidEditor(editor) {
-		return ErrInvalidEditor
+	if len(argv) == 0 {
+		var err error
+		if argv, err = envEditor(); err != nil {
+			return err
+		}
 	}
 
-	cmd := exec.Command(editor, path)
+	cmd := editorCommand(argv, path, repo)
 	cmd.Dir = path
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
@@ -92,39 +67,96 @@ func OpenInEditor(path, repoName string) error {
 	return cmd.Run()
 }
 
-// escapeLuaString escapes a string for safe use in a Lua string literal.
-// This prevents injection attacks when interpolating paths into Lua code.
-func escapeLuaString(s string) string {
-	var b strings.Builder
+// envEditor returns $EDITOR as an argv, split like the shell splits words,
+// so quoted arguments (even empty ones, as for emacsclient -a) are kept
+// whole. No shell runs it, so expansions and operators are rejected by
+// isValidEditor.
+func envEditor() ([]string, error) {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		return nil, ErrNoEditor
+	}
+	if !isValidEditor(editor) {
+		return nil, ErrInvalidEditor
+	}
+	argv, ok := splitWords(editor)
+	if !ok || len(argv) == 0 {
+		return nil, ErrInvalidEditor
+	}
+	return argv, nil
+}
+
+// splitWords splits s at unquoted whitespace and removes the single and
+// double quotes around parts of words. Returns false for an unterminated
+// quote.
+func splitWords(s string) ([]string, bool) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
 	for _, r := range s {
-		switch r {
-		case '\\':
-			b.WriteString("\\\\")
-		case '"':
-			b.WriteString("\\\"")
-		case '\'':
-			b.WriteString("\\'")
-		case '\n':
-			b.WriteString("\\n")
-		case '\r':
-			b.WriteString("\\r")
-		case '\t':
-			b.WriteString("\\t")
-		case '\x00':
-			b.WriteString("\\0")
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
 		default:
-			b.WriteRune(r)
+			word.WriteRune(r)
+			inWord = true
 		}
 	}
-	return b.String()
+	if quote != 0 {
+		return nil, false
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, true
 }
 
-func openInNeovim(path, repoName, nvimAddr string) error {
-	// Escape the path for safe use in Lua string
-	safePath := escapeLuaString(path)
-	nvimCmd := fmt.Sprintf("<C-\\><C-n>:lua package.loaded['fuzzyrepo']=nil; require('fuzzyrepo').open_repo(\"%s\")<CR>", safePath)
-	cmd := exec.Command("nvim", "--server", nvimAddr, "--remote-send", nvimCmd)
-	return cmd.Run()
+// openRepoLua opens a repo through the plugin. Arguments are passed as
+// "...", never interpolated into the code. The module isn't reloaded, so it
+// keeps its setup() options and session state.
+const openRepoLua = `return require('fuzzyrepo').open_repo(...)`
+
+// openInNeovim opens path in the Neovim at nvimAddr over msgpack-RPC,
+// passing the open strategy (empty = the plugin's default) and repo
+// metadata. Errors raised by the plugin are reported.
+func openInNeovim(path string, repo Repository, strategy, nvimAddr string) error {
+	client, err := dialNvim(nvimAddr)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	opts := map[string]any{
+		"repo": map[string]any{
+			"name":        repo.Name,
+			"full_name":   repo.FullName,
+			"owner":       repo.Owner,
+			"affiliation": repo.Affiliation,
+			"language":    repo.Language,
+			"ssh_url":     repo.SSHURL,
+			"web_url":     webURL(repo),
+		},
+	}
+	if strategy != "" {
+		opts["strategy"] = strategy
+	}
+
+	_, err = client.execLua(openRepoLua, path, opts)
+	return err
 }
 
 func CopyToClipboard(text string) {
@@ -174,17 +206,23 @@ func EnsureLocal(repo Repository, config Config) (string, error) {
 		return repo.LocalPath, nil
 	}
 
-	return CloneRepo(repo, config)
+	// git output goes to stderr, stdout is kept for results such as the
+	// path printed by "fuzzyrepo ensure"
+	path, err := index.Clone(context.Background(), repo, config.indexConfig(), os.Stderr)
+	if err != nil {
+		return path, err
+	}
+
+	reportHookError(runHooks(context.Background(), hookPostClone, repo, path, config))
+	return path, nil
 }
 
 func OpenInBrowser(repo Repository) error {
-	url := fmt.Sprintf("https://github.com/%s/%s", repo.Owner, repo.Name)
-	return openURL(url)
+	return openURL(webURL(repo))
 }
 
 func OpenPRs(repo Repository) error {
-	url := fmt.Sprintf("https://github.com/%s/%s/pulls", repo.Owner, repo.Name)
-	return openURL(url)
+	return openURL(webURL(repo) + "/pulls")
 }
 
 func openURL(url string) error {
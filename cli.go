@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/wealthystudent/fuzzyrepo/index"
+)
+
+// repoEntry is a repo as printed by "fuzzyrepo list --json" and
+// "fuzzyrepo ensure --json", and as sent by the daemon
+type repoEntry struct {
+	Repository
+	WebURL     string `json:"web_url"`
+	UsageCount int    `json:"usage_count"`
+
+	// Git status of the local clone, only known by the daemon
+	Branch string `json:"branch,omitempty"`
+	Dirty  bool   `json:"dirty,omitempty"`
+}
+
+func newRepoEntry(repo Repository, usage UsageData, status map[string]LocalStatus) repoEntry {
+	entry := repoEntry{
+		Repository: repo,
+		WebURL:     webURL(repo),
+		UsageCount: usage[strings.ToLower(repo.FullName)].Count,
+	}
+	if s, ok := status[repo.LocalPath]; ok && repo.ExistsLocal {
+		entry.Branch = s.Branch
+		entry.Dirty = s.Dirty
+	}
+	return entry
+}
+
+// queryRepos applies the config filters (unless p.All), the query and sort
+// mode, and the limit. The result is best first.
+func queryRepos(repos []Repository, usage UsageData, config Config, p listParams) ([]Repository, error) {
+	mode := index.SortFrecency
+	if p.Sort != "" {
+		mode = index.SortMode(p.Sort)
+		if !slices.Contains(index.SortModes, mode) {
+			return nil, fmt.Errorf("invalid sort mode %q, expected one of %s", mode, joinSortModes())
+		}
+	}
+
+	if !p.All {
+		repos = filterRepos(repos, config.Filter())
+	}
+	results := index.Rank(repos, p.Query, usage, mode)
+	if p.Limit > 0 && len(results) > p.Limit {
+		results = results[:p.Limit]
+	}
+	return results, nil
+}
+
+// listReposFromCache answers a list request from the cache files, for when
+// no daemon is running
+func listReposFromCache(p listParams) ([]repoEntry, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("could not load config: %w", err)
+	}
+	repos, err := loadRepoCache()
+	if err != nil {
+		return nil, fmt.Errorf("could not load repo cache: %w", err)
+	}
+	usage, _ := LoadUsage()
+
+	results, err := queryRepos(repos, usage, config, p)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]repoEntry, len(results))
+	for i, r := range results {
+		entries[i] = newRepoEntry(r, usage, nil)
+	}
+	return entries, nil
+}
+
+// runListCommand implements "fuzzyrepo list": the cached repos matching the
+// config filters, best first, one full name per line or as a JSON array.
+// Editor pickers use it so they rank repos the same way the TUI does.
+func runListCommand(args []string) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print a JSON array with repo metadata")
+	query := fs.String("query", "", "only repos fuzzy matching `text`")
+	sortFlag := fs.String("sort", string(index.SortFrecency), "sort `mode`: "+joinSortModes())
+	limit := fs.Int("limit", 0, "print at most `n` repos (0 = all)")
+	all := fs.Bool("all", false, "ignore the repository filters of the config")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fuzzyrepo list [--json] [--query text] [--sort mode] [--limit n] [--all]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	if !slices.Contains(index.SortModes, index.SortMode(*sortFlag)) {
+		fmt.Fprintf(os.Stderr, "invalid sort mode %q, expected one of %s\n", *sortFlag, joinSortModes())
+		return 2
+	}
+
+	p := listParams{Query: *query, Sort: *sortFlag, Limit: *limit, All: *all}
+	var entries []repoEntry
+	err := daemonCall("search", p, &entries)
+	if errors.Is(err, ErrNoDaemon) {
+		entries, err = listReposFromCache(p)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if err := printRepoList(os.Stdout, entries, *asJSON); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func joinSortModes() string {
+	names := make([]string, len(index.SortModes))
+	for i, mode := range index.SortModes {
+		names[i] = string(mode)
+	}
+	return strings.Join(names, ", ")
+}
+
+func printRepoList(w io.Writer, entries []repoEntry, asJSON bool) error {
+	if !asJSON {
+		for _, e := range entries {
+			fmt.Fprintln(w, e.FullName)
+		}
+		return nil
+	}
+	return json.NewEncoder(w).Encode(entries)
+}
+
+// findCachedRepo looks up a repo by full name, ignoring case, through the
+// daemon or in the cache file
+func findCachedRepo(fullName string) (Repository, error) {
+	var entry repoEntry
+	err := daemonCall("get", repoParams{FullName: fullName}, &entry)
+	if err == nil {
+		entry.ComputeSearchText()
+		return entry.Repository, nil
+	}
+	if !errors.Is(err, ErrNoDaemon) {
+		return Repository{}, err
+	}
+
+	repos, err := loadRepoCache()
+	if err != nil {
+		return Repository{}, fmt.Errorf("could not load repo cache: %w", err)
+	}
+	for _, r := range repos {
+		if strings.EqualFold(r.FullName, fullName) {
+			return r, nil
+		}
+	}
+	return Repository{}, fmt.Errorf("%s is not in the repo cache", fullName)
+}
+
+// ensureEntry is the repo printed by "fuzzyrepo ensure --json", with the
+// Neovim open strategy of the config for pickers opening it
+type ensureEntry struct {
+	repoEntry
+	OpenStrategy string `json:"open_strategy,omitempty"`
+}
+
+// runEnsureCommand implements "fuzzyrepo ensure owner/repo": clone the repo
+// if needed (running post_clone hooks), record the usage and print its local
+// path. Git and hook output go to stderr, so stdout only carries the result.
+func runEnsureCommand(args []string) int {
+	fs := flag.NewFlagSet("ensure", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the repo as JSON instead of its path")
+	noUsage := fs.Bool("no-usage", false, "don't record the usage for frecency")
+	preOpen := fs.Bool("pre-open", false, "run the pre_open hooks, for callers opening the repo")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fuzzyrepo ensure [--json] [--no-usage] [--pre-open] <owner/repo>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	fullName := fs.Arg(0)
+	if !validFullName(fullName) {
+		fmt.Fprintf(os.Stderr, "invalid repo %q, expected owner/repo\n", fullName)
+		return 2
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not load config:", err)
+		return 1
+	}
+	repo, err := findCachedRepo(fullName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	path, err := EnsureLocal(repo, config)
+	if err != nil && !errors.Is(err, ErrAlreadyExists) {
+		fmt.Fprintln(os.Stderr, "Clone failed:", err)
+		return 1
+	}
+	repo.LocalPath = path
+	repo.ExistsLocal = true
+
+	if !*noUsage {
+		_ = RecordUsage(repo)
+	}
+	if *preOpen {
+		reportHookError(runHooks(context.Background(), hookPreOpen, repo, path, config))
+	}
+
+	if !*asJSON {
+		fmt.Println(path)
+		return 0
+	}
+	usage, _ := LoadUsage()
+	entry := ensureEntry{repoEntry: newRepoEntry(repo, usage, nil), OpenStrategy: config.Neovim.OpenStrategy}
+	if err := json.NewEncoder(os.Stdout).Encode(entry); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// runHookCommand implements "fuzzyrepo hook <event> owner/repo": run the
+// event's hooks for a repo opened by someone else, such as the Neovim
+// pickers running post_open after opening it
+func runHookCommand(args []string) int {
+	fs := flag.NewFlagSet("hook", flag.ContinueOnError)
+	path := fs.String("path", "", "local path of the repo (default: its path in the repo cache)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fuzzyrepo hook [--path dir] <post_clone|pre_open|post_open> <owner/repo>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+	event, fullName := hookEvent(fs.Arg(0)), fs.Arg(1)
+	if !slices.Contains([]hookEvent{hookPostClone, hookPreOpen, hookPostOpen}, event) {
+		fmt.Fprintf(os.Stderr, "unknown hook %q, expected post_clone, pre_open or post_open\n", event)
+		return 2
+	}
+	if !validFullName(fullName) {
+		fmt.Fprintf(os.Stderr, "invalid repo %q, expected owner/repo\n", fullName)
+		return 2
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not load config:", err)
+		return 1
+	}
+	repo, err := findCachedRepo(fullName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *path == "" {
+		if !repo.ExistsLocal || repo.LocalPath == "" {
+			fmt.Fprintf(os.Stderr, "%s has no local clone, pass --path\n", fullName)
+			return 1
+		}
+		*path = repo.LocalPath
+	}
+
+	if err := runHooks(context.Background(), event, repo, *path, config); err != nil {
+		reportHookError(err)
+		return 1
+	}
+	return 0
+}
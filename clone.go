@@ -0,0 +1,331 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/wealthystudent/fuzzyrepo/index"
+)
+
+// cloneProgress is the latest progress line reported by git clone
+type cloneProgress struct {
+	Phase   string // e.g. "Receiving objects"
+	Percent int
+}
+
+// cloneProgressMsg is sent for every progress update of a running clone
+type cloneProgressMsg cloneProgress
+
+// cloneDoneMsg is sent once a clone finished, failed or was cancelled
+type cloneDoneMsg struct {
+	path     string
+	err      error
+	canceled bool
+}
+
+// cloneJob is a git clone running in the background while the UI stays up
+type cloneJob struct {
+	repo     Repository
+	action   Action // Action to run once the clone succeeded
+	dest     string
+	progress cloneProgress
+	cancel   context.CancelFunc
+	events   <-chan tea.Msg
+	quitting bool // Quit the UI once the cancelled clone has cleaned up
+	hooks    bool // Clone finished, post_clone hooks are running
+
+	custom *CustomCommand // Custom command to run instead of action
+}
+
+// progressPattern matches git progress lines such as
+// "Receiving objects:  45% (123/456), 1.20 MiB | 2.30 MiB/s"
+var progressPattern = regexp.MustCompile(`^(?:remote: )?([A-Za-z ]+):\s+(\d+)%`)
+
+// parseCloneProgress extracts the phase and percentage from a progress line
+func parseCloneProgress(line string) (cloneProgress, bool) {
+	m := progressPattern.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return cloneProgress{}, false
+	}
+	percent, err := strconv.Atoi(m[2])
+	if err != nil {
+		return cloneProgress{}, false
+	}
+	return cloneProgress{Phase: m[1], Percent: percent}, true
+}
+
+// scanProgressLines splits git's stderr on both \r and \n, since progress
+// updates overwrite the current line with carriage returns
+func scanProgressLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// startClone runs git clone for repo in the background. Progress and the
+// final result are delivered as messages through the returned command.
+func startClone(repo Repository, action Action, config Config) (*cloneJob, tea.Cmd, error) {
+	dest, args, err := index.PrepareClone(repo, config.indexConfig())
+	if errors.Is(err, ErrAlreadyExists) {
+		return &cloneJob{repo: repo, action: action, dest: dest}, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Ask for progress output even though stderr is not a terminal
+	args = append([]string{"clone", "--progress"}, args[1:]...)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("%w: %v", ErrCloneFailed, err)
+	}
+
+	events := make(chan tea.Msg, 16)
+	go func() {
+		defer close(events)
+		tail := readCloneProgress(stderr, events)
+		err := cmd.Wait()
+
+		switch {
+		case ctx.Err() != nil:
+			_ = os.RemoveAll(dest)
+			events <- cloneDoneMsg{canceled: true}
+		case err != nil:
+			_ = os.RemoveAll(dest)
+			if tail != "" {
+				err = fmt.Errorf("%w: %s", ErrCloneFailed, tail)
+			} else {
+				err = fmt.Errorf("%w: %v", ErrCloneFailed, err)
+			}
+			events <- cloneDoneMsg{err: err}
+		default:
+			events <- cloneDoneMsg{path: dest}
+		}
+	}()
+
+	job := &cloneJob{
+		repo:   repo,
+		action: action,
+		dest:   dest,
+		cancel: cancel,
+		events: events,
+	}
+	return job, waitForCloneEvent(events), nil
+}
+
+// readCloneProgress forwards progress updates until stderr closes and returns
+// the last non-progress line, which holds git's error message on failure
+func readCloneProgress(r io.Reader, events chan<- tea.Msg) string {
+	var tail string
+	scanner := bufio.NewScanner(r)
+	scanner.Split(scanProgressLines)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if p, ok := parseCloneProgress(line); ok {
+			events <- cloneProgressMsg(p)
+			continue
+		}
+		if !strings.HasPrefix(line, "Cloning into") {
+			tail = line
+		}
+	}
+	return tail
+}
+
+// waitForCloneEvent returns a command that waits for the next clone event
+func waitForCloneEvent(events <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-events
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+// actionNeedsLocal reports whether an action needs a local clone to run
+func actionNeedsLocal(action Action) bool {
+	switch action {
+	case ActionOpen, ActionCopy, ActionTmuxSession, ActionTmuxWindow:
+		return true
+	}
+	return false
+}
+
+// chooseRepo selects the repo under the cursor for action and quits the UI.
+// Remote-only repos are cloned first, inside the UI.
+func (m Model) chooseRepo(action Action) (tea.Model, tea.Cmd) {
+	if len(m.results) == 0 {
+		return m, nil
+	}
+	r := m.results[m.cursor]
+
+	if actionNeedsLocal(action) && !(r.ExistsLocal && r.LocalPath != "") {
+		job, cmd, err := startClone(r, action, m.config)
+		if errors.Is(err, ErrAlreadyExists) {
+			r.LocalPath = job.dest
+			r.ExistsLocal = true
+		} else if err != nil {
+			m.setMessageWithHint(err.Error(), ErrorLevel, "press enter to retry")
+			return m, nil
+		} else {
+			m.clone = job
+			return m, cmd
+		}
+	}
+
+	m.selectedRepo = &r
+	m.selectedAction = action
+	return m, tea.Quit
+}
+
+// updateClone handles keys and events while a clone is running
+func (m Model) updateClone(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case cloneProgressMsg:
+		m.clone.progress = cloneProgress(msg)
+		return m, waitForCloneEvent(m.clone.events)
+
+	case cloneDoneMsg:
+		job := m.clone
+		m.clone = nil
+		// The clone is over, release its context before hooks get their own
+		job.cancel()
+
+		if msg.canceled {
+			if job.quitting {
+				return m, tea.Quit
+			}
+			m.setMessage("clone of "+job.repo.FullName+" cancelled", InfoLevel)
+			return m, m.clearMessageAfter(5 * time.Second)
+		}
+		if msg.err != nil {
+			m.setMessageWithHint(msg.err.Error(), ErrorLevel, "press enter to retry")
+			return m, nil
+		}
+
+		job.repo.LocalPath = msg.path
+		job.repo.ExistsLocal = true
+		m.markCloned(job.repo)
+
+		if len(m.config.GetHooks(job.repo.FullName).PostClone) > 0 {
+			ctx, cancel := context.WithCancel(context.Background())
+			job.cancel = cancel
+			job.hooks = true
+			m.clone = job
+			return m, runHooksCmd(ctx, hookPostClone, job.repo, msg.path, m.config)
+		}
+		return m.finishClone(job)
+
+	case hooksDoneMsg:
+		job := m.clone
+		m.clone = nil
+		job.cancel()
+
+		// Hook failures are reported once the UI exits, the open goes ahead
+		if msg.err != nil {
+			m.hookErrs = append(m.hookErrs, msg.err)
+		}
+		if job.quitting {
+			return m, tea.Quit
+		}
+		return m.finishClone(job)
+
+	case tea.KeyMsg:
+		model, cmd, _ := dispatchKey(cloneKeys(), m, msg)
+		return model, cmd
+	}
+
+	return m, nil
+}
+
+// finishClone runs the action the clone was started for
+func (m Model) finishClone(job *cloneJob) (tea.Model, tea.Cmd) {
+	if job.custom != nil {
+		return m.finishCustom(job.repo, *job.custom)
+	}
+	r := job.repo
+	m.selectedRepo = &r
+	m.selectedAction = job.action
+	return m, tea.Quit
+}
+
+// markCloned records a fresh clone in the in-memory repo lists
+func (m *Model) markCloned(repo Repository) {
+	for _, list := range [][]Repository{m.cache, m.all, m.results} {
+		for i := range list {
+			if list[i].FullName == repo.FullName {
+				list[i] = repo
+			}
+		}
+	}
+}
+
+// progressBar renders a percentage as a bar of the given width
+func progressBar(percent, width int) string {
+	filled := clamp(percent, 0, 100) * width / 100
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func (m Model) buildCloneBox() string {
+	job := m.clone
+	if job.hooks {
+		lines := []string{
+			inputTextStyle.Render("Cloned " + job.repo.FullName),
+			dimStyle.Render("into " + abbreviateHome(job.dest)),
+			"",
+			repoNameStyle.Render("Running post_clone hooks..."),
+			"",
+			keybindStyle.Render("esc skip hooks"),
+		}
+		return overlayStyle.Render(strings.Join(lines, "\n"))
+	}
+
+	phase := job.progress.Phase
+	if phase == "" {
+		phase = "Connecting"
+	}
+
+	lines := []string{
+		inputTextStyle.Render("Cloning " + job.repo.FullName),
+		dimStyle.Render("into " + abbreviateHome(job.dest)),
+		"",
+		repoNameStyle.Render(fmt.Sprintf("%-20s %3d%%", phase, job.progress.Percent)),
+		localYesStyle.Render(progressBar(job.progress.Percent, 40)),
+		"",
+		keybindStyle.Render("esc cancel"),
+	}
+
+	return overlayStyle.Render(strings.Join(lines, "\n"))
+}
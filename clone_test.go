@@ -0,0 +1,31 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCloneDoneReleasesContext(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  cloneDoneMsg
+	}{
+		{"failed", cloneDoneMsg{err: errors.New("clone failed")}},
+		{"cloned", cloneDoneMsg{path: "/src/api"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testModel(t)
+			canceled := false
+			m.clone = &cloneJob{
+				repo:   Repository{FullName: "acme/api"},
+				action: ActionCopy,
+				cancel: func() { canceled = true },
+			}
+			m.updateClone(tt.msg)
+			if !canceled {
+				t.Error("the clone's context was not cancelled once it finished")
+			}
+		})
+	}
+}
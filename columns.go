@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// ColumnConfig configures one column of the result list.
+// A column has either a fixed Width or a Flex share of the remaining space.
+type ColumnConfig struct {
+	Name     string `yaml:"name"`                // Column id, see columnDefs
+	Width    int    `yaml:"width,omitempty"`     // Fixed width in terminal cells
+	Flex     int    `yaml:"flex,omitempty"`      // Share of the space left after fixed columns
+	MinWidth int    `yaml:"min_width,omitempty"` // Minimum width for flex columns
+	Priority int    `yaml:"priority,omitempty"`  // Lowest priority is dropped first on narrow terminals
+}
+
+// DefaultColumns reproduces the classic repo/local/owner layout
+func DefaultColumns() []ColumnConfig {
+	return []ColumnConfig{
+		{Name: "name", Flex: 3, MinWidth: 15, Priority: 3},
+		{Name: "local", Width: 6, Priority: 1},
+		{Name: "owner", Flex: 1, MinWidth: 10, Priority: 2},
+	}
+}
+
+// columnDef describes how a column is titled, filled and styled
+type columnDef struct {
+	title    string
+	minWidth int // Default minimum width for flex columns
+	value    func(m *Model, r Repository) string
+	style    func(r Repository, m *Model, selected bool) lipgloss.Style
+}
+
+// columnSeparatorWidth is the number of blank cells between two columns
+const columnSeparatorWidth = 2
+
+var columnDefs = map[string]columnDef{
+	"name": {
+		title:    "REPO",
+		minWidth: 15,
+		value:    func(m *Model, r Repository) string { return r.Name },
+		style:    nameColumnStyle,
+	},
+	"owner": {
+		title:    "OWNER",
+		minWidth: 10,
+		value:    func(m *Model, r Repository) string { return r.Owner },
+		style:    dimColumnStyle,
+	},
+	"full_name": {
+		title:    "REPO",
+		minWidth: 20,
+		value:    func(m *Model, r Repository) string { return r.FullName },
+		style:    nameColumnStyle,
+	},
+	"local": {
+		title:    "LOCAL",
+		minWidth: 6,
+		value: func(m *Model, r Repository) string {
+			if r.ExistsLocal {
+				return "local"
+			}
+			return "remote"
+		},
+		style: func(r Repository, m *Model, selected bool) lipgloss.Style {
+			switch {
+			case r.ExistsLocal && selected:
+				return localYesCursorStyle
+			case r.ExistsLocal:
+				return localYesStyle
+			case selected:
+				return localNoCursorStyle
+			default:
+				return localNoStyle
+			}
+		},
+	},
+	"affiliation": {
+		title:    "AFFILIATION",
+		minWidth: 6,
+		value:    func(m *Model, r Repository) string { return affiliationLabel(r.Affiliation) },
+		style:    dimColumnStyle,
+	},
+	"language": {
+		title:    "LANGUAGE",
+		minWidth: 6,
+		value:    func(m *Model, r Repository) string { return r.Language },
+		style:    dimColumnStyle,
+	},
+	"stars": {
+		title:    "STARS",
+		minWidth: 5,
+		value: func(m *Model, r Repository) string {
+			if r.Stars == 0 {
+				return ""
+			}
+			return formatCount(r.Stars)
+		},
+		style: dimColumnStyle,
+	},
+	"pushed": {
+		title:    "PUSHED",
+		minWidth: 6,
+		value: func(m *Model, r Repository) string {
+			if r.PushedAt.IsZero() {
+				return ""
+			}
+			return formatAge(r.PushedAt)
+		},
+		style: dimColumnStyle,
+	},
+	"branch": {
+		title:    "BRANCH",
+		minWidth: 8,
+		value: func(m *Model, r Repository) string {
+			return m.localStatus[r.LocalPath].Branch
+		},
+		style: dimColumnStyle,
+	},
+	"dirty": {
+		title:    "DIRTY",
+		minWidth: 5,
+		value: func(m *Model, r Repository) string {
+			if r.ExistsLocal && m.localStatus[r.LocalPath].Dirty {
+				return "*"
+			}
+			return ""
+		},
+		style: func(r Repository, m *Model, selected bool) lipgloss.Style {
+			if selected {
+				return dirtyCursorStyle
+			}
+			return dirtyStyle
+		},
+	},
+	"path": {
+		title:    "PATH",
+		minWidth: 10,
+		value:    func(m *Model, r Repository) string { return abbreviateHome(r.LocalPath) },
+		style:    dimColumnStyle,
+	},
+	"usage": {
+		title:    "USED",
+		minWidth: 4,
+		value: func(m *Model, r Repository) string {
+			count := m.usage[strings.ToLower(r.FullName)].Count
+			if count == 0 {
+				return ""
+			}
+			return strconv.Itoa(count)
+		},
+		style: dimColumnStyle,
+	},
+}
+
+func nameColumnStyle(r Repository, m *Model, selected bool) lipgloss.Style {
+	if selected {
+		return cursorStyle
+	}
+	return repoNameStyle
+}
+
+func dimColumnStyle(r Repository, m *Model, selected bool) lipgloss.Style {
+	if selected {
+		return cursorStyle
+	}
+	return ownerStyle
+}
+
+// affiliationLabel shortens affiliation values for display
+func affiliationLabel(affiliation string) string {
+	switch affiliation {
+	case "owner", "":
+		return "owner"
+	case "collaborator":
+		return "collab"
+	case "organization_member":
+		return "org"
+	default:
+		return affiliation
+	}
+}
+
+// validateColumns checks that every configured column is known and sized sanely
+func validateColumns(cols []ColumnConfig) error {
+	for i, col := range cols {
+		if _, ok := columnDefs[col.Name]; !ok {
+			return fmt.Errorf("columns[%d]: unknown column %q", i, col.Name)
+		}
+		if col.Width < 0 || col.Flex < 0 || col.MinWidth < 0 {
+			return fmt.Errorf("columns[%d]: width, flex and min_width cannot be negative", i)
+		}
+		if col.Width > 0 && col.Flex > 0 {
+			return fmt.Errorf("columns[%d]: set either width or flex, not both", i)
+		}
+	}
+	return nil
+}
+
+// columnsNeedLocalStatus reports whether any column shows git working tree state
+func columnsNeedLocalStatus(cols []ColumnConfig) bool {
+	for _, col := range cols {
+		if col.Name == "branch" || col.Name == "dirty" {
+			return true
+		}
+	}
+	return false
+}
+
+// columnLayout is a column resolved to a concrete width for one render
+type columnLayout struct {
+	def   columnDef
+	width int
+}
+
+// layoutColumns assigns widths to the configured columns for the given
+// terminal width. Columns that don't fit are dropped, lowest priority first
+// (rightmost first among equal priorities).
+func layoutColumns(cols []ColumnConfig, width int) []columnLayout {
+	visible := make([]ColumnConfig, 0, len(cols))
+	for _, col := range cols {
+		if _, ok := columnDefs[col.Name]; ok {
+			visible = append(visible, col)
+		}
+	}
+
+	minWidth := func(col ColumnConfig) int {
+		if col.Width > 0 {
+			return col.Width
+		}
+		if col.MinWidth > 0 {
+			return col.MinWidth
+		}
+		return columnDefs[col.Name].minWidth
+	}
+
+	for len(visible) > 1 {
+		needed := columnSeparatorWidth * (len(visible) - 1)
+		for _, col := range visible {
+			needed += minWidth(col)
+		}
+		if needed <= width {
+			break
+		}
+
+		drop := len(visible) - 1
+		for i := len(visible) - 1; i >= 0; i-- {
+			if visible[i].Priority < visible[drop].Priority {
+				drop = i
+			}
+		}
+		visible = append(visible[:drop], visible[drop+1:]...)
+	}
+
+	// Fixed columns take their width, flex columns start at their minimum
+	// and share whatever is left proportionally
+	remaining := width - columnSeparatorWidth*(len(visible)-1)
+	totalFlex := 0
+	widths := make([]int, len(visible))
+	for i, col := range visible {
+		widths[i] = minWidth(col)
+		remaining -= widths[i]
+		if col.Width == 0 {
+			totalFlex += max(col.Flex, 1)
+		}
+	}
+
+	if remaining > 0 && totalFlex > 0 {
+		extra := remaining
+		lastFlex := -1
+		for i, col := range visible {
+			if col.Width > 0 {
+				continue
+			}
+			share := extra * max(col.Flex, 1) / totalFlex
+			widths[i] += share
+			remaining -= share
+			lastFlex = i
+		}
+		widths[lastFlex] += remaining
+	}
+
+	layout := make([]columnLayout, len(visible))
+	for i, col := range visible {
+		layout[i] = columnLayout{def: columnDefs[col.Name], width: widths[i]}
+	}
+	return layout
+}
+
+// renderHeader renders the column titles
+func renderHeader(layout []columnLayout) string {
+	sep := bgOnlyStyle.Render(strings.Repeat(" ", columnSeparatorWidth))
+	cells := make([]string, len(layout))
+	for i, col := range layout {
+		cells[i] = headerStyle.Render(padOrTrim(col.def.title, col.width))
+	}
+	return strings.Join(cells, sep)
+}
+
+// renderRow renders one repository as a row of cells
+func (m *Model) renderRow(layout []columnLayout, r Repository, selected bool) string {
+	sepStyle := bgOnlyStyle
+	if selected {
+		sepStyle = cursorSepStyle
+	}
+	sep := sepStyle.Render(strings.Repeat(" ", columnSeparatorWidth))
+
+	cells := make([]string, len(layout))
+	for i, col := range layout {
+		text := padOrTrim(col.def.value(m, r), col.width)
+		cells[i] = col.def.style(r, m, selected).Render(text)
+	}
+	return strings.Join(cells, sep)
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Custom command modes
+const (
+	commandModeExit = "exit" // Run after the picker exits, attached to the terminal
+	commandModeTUI  = "tui"  // Run in the background, the picker stays open
+)
+
+// CustomCommand is a user-defined command palette entry. Exactly one of
+// Run, URL and Copy is set; all of them are templates, see expandCommandTemplate.
+type CustomCommand struct {
+	Key        string   `yaml:"key"`                   // Palette shortcut, one character
+	Name       string   `yaml:"name"`                  // Shown in the palette
+	Run        []string `yaml:"run,omitempty"`         // Command argv, run without a shell
+	URL        string   `yaml:"url,omitempty"`         // URL to open in the browser
+	Copy       string   `yaml:"copy,omitempty"`        // Text to copy to the clipboard
+	NeedsLocal bool     `yaml:"needs_local,omitempty"` // Clone the repo first; required for {path}
+	Mode       string   `yaml:"mode,omitempty"`        // "exit" (default) or "tui"
+}
+
+// customDoneMsg is sent when a custom command run inside the UI finished
+type customDoneMsg struct {
+	name   string
+	output string
+	err    error
+}
+
+// placeholderPattern matches the {name} placeholders of commands and editor argv
+var placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// commandPlaceholders lists the supported {placeholder} names of custom commands
+var commandPlaceholders = []string{"path", "full_name", "owner", "name", "ssh_url", "web_url"}
+
+// webURL returns the GitHub page of repo
+func webURL(repo Repository) string {
+	return fmt.Sprintf("https://github.com/%s/%s", repo.Owner, repo.Name)
+}
+
+// expandCommandTemplate replaces {path}, {full_name}, {owner}, {name},
+// {ssh_url} and {web_url} in s with the values of repo
+func expandCommandTemplate(s string, repo Repository) string {
+	values := map[string]string{
+		"path":      repo.LocalPath,
+		"full_name": repo.FullName,
+		"owner":     repo.Owner,
+		"name":      repo.Name,
+		"ssh_url":   repo.SSHURL,
+		"web_url":   webURL(repo),
+	}
+	return placeholderPattern.ReplaceAllStringFunc(s, func(p string) string {
+		if v, ok := values[p[1:len(p)-1]]; ok {
+			return v
+		}
+		return p
+	})
+}
+
+func (c CustomCommand) mode() string {
+	if c.Mode == "" {
+		return commandModeExit
+	}
+	return c.Mode
+}
+
+// templates returns every template string of the command
+func (c CustomCommand) templates() []string {
+	return append(slices.Clone(c.Run), c.URL, c.Copy)
+}
+
+// validate checks the command; field is the config key used in errors
+func (c CustomCommand) validate(field string) error {
+	if utf8.RuneCountInString(c.Key) != 1 || c.Key == " " {
+		return fmt.Errorf("%s.key must be a single character (got %q)", field, c.Key)
+	}
+	if c.Key == "?" {
+		return fmt.Errorf("%s.key %q is used by the palette help", field, c.Key)
+	}
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("%s.name cannot be empty", field)
+	}
+
+	kinds := 0
+	for _, set := range []bool{len(c.Run) > 0, c.URL != "", c.Copy != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("%s must set exactly one of run, url or copy", field)
+	}
+
+	switch c.Mode {
+	case "", commandModeExit:
+	case commandModeTUI:
+		if c.Copy != "" {
+			return fmt.Errorf("%s: copy commands can't use mode %q, the clipboard is set after the picker exits", field, c.Mode)
+		}
+	default:
+		return fmt.Errorf("%s.mode must be %s or %s (got %q)", field, commandModeExit, commandModeTUI, c.Mode)
+	}
+
+	for _, tmpl := range c.templates() {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(tmpl, -1) {
+			if !slices.Contains(commandPlaceholders, m[1]) {
+				return fmt.Errorf("%s: unknown placeholder {%s} (use {%s})", field, m[1], strings.Join(commandPlaceholders, "}, {"))
+			}
+			if m[1] == "path" && !c.NeedsLocal {
+				return fmt.Errorf("%s: {path} needs needs_local: true", field)
+			}
+		}
+	}
+	return nil
+}
+
+// shadowedCommandWarnings names the built-in palette commands hidden by a
+// custom command with the same key. Custom commands win, so a key that
+// became a built-in in a later release keeps running the user's command.
+func shadowedCommandWarnings(commands []CustomCommand) []string {
+	var warnings []string
+	for _, builtin := range (&Model{}).builtinCommands() {
+		for _, c := range commands {
+			if c.Key == builtin.key {
+				warnings = append(warnings, fmt.Sprintf("Custom command %q hides the built-in %q on key %s", c.Name, builtin.name, c.Key))
+				break
+			}
+		}
+	}
+	return warnings
+}
+
+// command builds the process for a run command on repo. With attached set
+// it uses the terminal, otherwise the caller captures the output.
+func (c CustomCommand) command(repo Repository, attached bool) *exec.Cmd {
+	argv := make([]string, len(c.Run))
+	for i, arg := range c.Run {
+		argv[i] = expandCommandTemplate(arg, repo)
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	if repo.ExistsLocal && repo.LocalPath != "" {
+		cmd.Dir = repo.LocalPath
+	}
+	cmd.Env = append(os.Environ(), "FUZZYREPO=1")
+	if attached {
+		cmd.Stdin = os.Stdin
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+	return cmd
+}
+
+// runCustomCommandCmd runs a "tui" mode command in the background
+func runCustomCommandCmd(c CustomCommand, repo Repository) tea.Cmd {
+	return func() tea.Msg {
+		if c.URL != "" {
+			return customDoneMsg{name: c.Name, err: openURL(expandCommandTemplate(c.URL, repo))}
+		}
+
+		var out bytes.Buffer
+		cmd := c.command(repo, false)
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		cmd.WaitDelay = time.Second
+		err := cmd.Run()
+		return customDoneMsg{name: c.Name, output: out.String(), err: err}
+	}
+}
+
+// executeCustomCommand runs an "exit" mode command after the picker closed
+func executeCustomCommand(c CustomCommand, repo Repository, config Config) error {
+	if c.NeedsLocal {
+		path, err := EnsureLocal(repo, config)
+		if err != nil && !errors.Is(err, ErrAlreadyExists) {
+			return err
+		}
+		repo.LocalPath = path
+		repo.ExistsLocal = true
+	}
+
+	switch {
+	case c.URL != "":
+		return openURL(expandCommandTemplate(c.URL, repo))
+	case c.Copy != "":
+		text := expandCommandTemplate(c.Copy, repo)
+		CopyToClipboard(text)
+		fmt.Println("Copied to clipboard:", text)
+		return nil
+	default:
+		return c.command(repo, true).Run()
+	}
+}
+
+// runCustom runs a custom command on the repo under the cursor, cloning it
+// first when the command needs a local copy
+func (m Model) runCustom(c CustomCommand) (tea.Model, tea.Cmd) {
+	if len(m.results) == 0 {
+		return m, nil
+	}
+	r := m.results[m.cursor]
+	if !c.NeedsLocal || (r.ExistsLocal && r.LocalPath != "") {
+		return m.finishCustom(r, c)
+	}
+
+	job, cmd, err := startClone(r, ActionCustom, m.config)
+	switch {
+	case errors.Is(err, ErrAlreadyExists):
+		r.LocalPath = job.dest
+		r.ExistsLocal = true
+		return m.finishCustom(r, c)
+	case err != nil:
+		m.setMessageWithHint(err.Error(), ErrorLevel, "press space "+c.Key+" to retry")
+		return m, nil
+	}
+	job.custom = &c
+	m.clone = job
+	return m, cmd
+}
+
+// finishCustom runs c on a repo that is local if c needs it: in the
+// background for "tui" mode, otherwise after the picker exits
+func (m Model) finishCustom(r Repository, c CustomCommand) (tea.Model, tea.Cmd) {
+	if c.mode() == commandModeTUI {
+		m.setMessage("running "+c.Name+"...", InfoLevel)
+		_ = RecordUsage(r)
+		return m, runCustomCommandCmd(c, r)
+	}
+	m.selectedRepo = &r
+	m.selectedAction = ActionCustom
+	m.selectedCustom = &c
+	return m, tea.Quit
+}
+
+// customDone reports the result of a "tui" mode command
+func (m Model) customDone(msg customDoneMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		text := fmt.Sprintf("%s failed: %v", msg.name, msg.err)
+		if tail := lastLine(msg.output); tail != "" {
+			text += ": " + tail
+		}
+		m.setMessage(text, ErrorLevel)
+		return m, nil
+	}
+	text := msg.name + " done"
+	if tail := lastLine(msg.output); tail != "" {
+		text += ": " + tail
+	}
+	m.setMessage(text, InfoLevel)
+	return m, m.clearMessageAfter(5 * time.Second)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestConfig(t *testing.T, yaml string) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	path := xdgConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCustomCommandShadowsBuiltin(t *testing.T) {
+	writeTestConfig(t, `
+commands:
+  - key: m
+    name: make
+    run: [make]
+`)
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig = %v, want a custom command on a built-in key to load", err)
+	}
+
+	m := newModel(nil, config, nil, time.Time{}, false)
+	var onM []string
+	for _, cmd := range m.getCommands() {
+		if cmd.key == "m" {
+			onM = append(onM, cmd.name)
+		}
+	}
+	if len(onM) != 1 || onM[0] != "make" {
+		t.Errorf("palette commands on m = %v, want only the custom one", onM)
+	}
+
+	var warned bool
+	for _, e := range m.messages.entries {
+		warned = warned || e.Level == WarningLevel && strings.Contains(e.Text, "open in tmux session")
+	}
+	if !warned {
+		t.Errorf("no warning about the hidden built-in in the message log: %+v", m.messages.entries)
+	}
+}
+
+func TestCustomCommandHelpKey(t *testing.T) {
+	writeTestConfig(t, `
+commands:
+  - key: "?"
+    name: help
+    run: [man, git]
+`)
+	if _, err := LoadConfig(); err == nil {
+		t.Error("LoadConfig accepted a custom command on the help key")
+	}
+}
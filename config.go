@@ -6,9 +6,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"regexp"
 	"runtime"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/wealthystudent/fuzzyrepo/index"
 	"gopkg.in/yaml.v3"
 )
 
@@ -17,37 +20,151 @@ type GitHubConfig struct {
 	Orgs        string `yaml:"orgs"`
 }
 
-// CloneRule defines a regex pattern to match repo full_name and a target directory
+// CloneRule is a clone path rule of the index, with the hooks and editor
+// of the repos it matches
 type CloneRule struct {
-	Pattern string `yaml:"pattern"` // Regex pattern to match against full_name (owner/repo)
-	Path    string `yaml:"path"`    // Target directory (repo name will be appended)
+	index.CloneRule `yaml:",inline"`
+
+	Hooks  *Hooks   `yaml:"hooks,omitempty"`  // Run after the global hooks for matching repos
+	Editor []string `yaml:"editor,omitempty"` // Editor argv for matching repos, see editorCommand
+}
+
+type CloneOptions = index.CloneOptions
+
+// Hooks are shell commands run in the repo directory at points of its
+// lifecycle, with the repo described in FUZZYREPO_* environment variables
+type Hooks struct {
+	PostClone []string      `yaml:"post_clone,omitempty"` // After a repo was cloned
+	PreOpen   []string      `yaml:"pre_open,omitempty"`   // Before the editor is started
+	PostOpen  []string      `yaml:"post_open,omitempty"`  // After the editor command returned
+	Timeout   time.Duration `yaml:"timeout,omitempty"`    // Per command, e.g. "2m" (default 1m)
+}
+
+// merge returns h with the commands of override appended and its timeout,
+// if set, replacing the original
+func (h Hooks) merge(override *Hooks) Hooks {
+	if override == nil {
+		return h
+	}
+	h.PostClone = append(append([]string{}, h.PostClone...), override.PostClone...)
+	h.PreOpen = append(append([]string{}, h.PreOpen...), override.PreOpen...)
+	h.PostOpen = append(append([]string{}, h.PostOpen...), override.PostOpen...)
+	if override.Timeout != 0 {
+		h.Timeout = override.Timeout
+	}
+	return h
+}
+
+// validate checks the hooks; field is the config key used in errors
+func (h Hooks) validate(field string) error {
+	if h.Timeout < 0 {
+		return fmt.Errorf("%s.timeout cannot be negative", field)
+	}
+	for _, event := range []hookEvent{hookPostClone, hookPreOpen, hookPostOpen} {
+		for _, command := range h.commands(event) {
+			if strings.TrimSpace(command) == "" {
+				return fmt.Errorf("%s.%s cannot contain empty commands", field, event)
+			}
+		}
+	}
+	return nil
+}
+
+// Neovim open strategies, see M.open_repo in lua/fuzzyrepo/init.lua
+var neovimOpenStrategies = []string{"tab", "cd", "split", "vsplit", "instance"}
+
+// NeovimConfig controls how repos open in the surrounding Neovim
+type NeovimConfig struct {
+	OpenStrategy string `yaml:"open_strategy,omitempty"` // tab, cd, split, vsplit or instance (empty = plugin setting)
+}
+
+// SyncConfig controls when the index is synced automatically. Manual
+// refreshes always run.
+type SyncConfig struct {
+	Auto           *bool         `yaml:"auto,omitempty"`            // Sync on startup and in the daemon when due (default true)
+	RemoteInterval time.Duration `yaml:"remote_interval,omitempty"` // Sync GitHub when the last sync is older, e.g. "12h" (default 168h)
+	LocalInterval  time.Duration `yaml:"local_interval,omitempty"`  // Scan the repo roots when the last scan is older (default 24h)
+}
+
+// minSyncInterval keeps a mistyped interval from syncing on every start
+const minSyncInterval = time.Minute
+
+// validate checks the intervals; field is the config key used in errors
+func (s SyncConfig) validate(field string) error {
+	for _, iv := range []struct {
+		key      string
+		interval time.Duration
+	}{{"remote_interval", s.RemoteInterval}, {"local_interval", s.LocalInterval}} {
+		if iv.interval < 0 {
+			return fmt.Errorf("%s.%s cannot be negative", field, iv.key)
+		}
+		if iv.interval != 0 && iv.interval < minSyncInterval {
+			return fmt.Errorf("%s.%s must be at least %s (got %s)", field, iv.key, formatInterval(minSyncInterval), formatInterval(iv.interval))
+		}
+	}
+	return nil
+}
+
+// formatInterval formats a sync interval for the config overlay and errors, dropping
+// the zero minutes and seconds of "168h0m0s". Zero (the default) is empty.
+func formatInterval(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
 }
 
 // ConfigFieldDescriptions maps config field indices to their descriptions
 // Used in the config overlay to show help text for the focused field
 var ConfigFieldDescriptions = map[int]string{
-	0: "Directories to scan for local git repositories (comma-separated absolute paths)",
-	1: "Default clone directory when clone rules are disabled or no rule matches",
-	2: "Enable regex-based clone rules. Press 'e' to edit config file and add rules:\n  clone_rules:\n    - pattern: \"^org/.*\"\n      path: /path/to/dir",
-	3: "Limit to specific GitHub organizations (comma-separated, empty = all orgs)",
-	4: "Show repositories you own (yes/no)",
-	5: "Show repositories you collaborate on (yes/no)",
-	6: "Show repositories from your organizations (yes/no)",
-	7: "Show local-only repositories not on GitHub (yes/no)",
+	0:  "Directories to scan for local git repositories (comma-separated absolute paths)",
+	1:  "Default clone directory when clone rules are disabled or no rule matches",
+	2:  "Enable regex-based clone rules, first matching rule wins. Press Ctrl+R to add, reorder and test rules, or Space to edit the config file:\n  clone_rules:\n    - pattern: \"^org/.*\"\n      path: /path/to/dir",
+	3:  "Limit to specific GitHub organizations (comma-separated, empty = all orgs)",
+	4:  "Show repositories you own (yes/no)",
+	5:  "Show repositories you collaborate on (yes/no)",
+	6:  "Show repositories from your organizations (yes/no)",
+	7:  "Show local-only repositories not on GitHub (yes/no)",
+	8:  "Sync on startup and in the daemon when the index is older than the intervals (yes/no). With no, only space r syncs, except to fill an empty index",
+	9:  "Sync GitHub repos when the last sync is older than this, e.g. 12h or 30m (empty = 168h, one week)",
+	10: "Rescan the repository dirs when the last scan is older than this, e.g. 1h (empty = 24h)",
 }
 
 type Config struct {
-	RepoRoots     []string     `yaml:"repo_roots"`
-	CloneRoot     string       `yaml:"clone_root"`
-	UseCloneRules bool         `yaml:"use_clone_rules"`       // Enable regex-based clone path rules
-	CloneRules    []CloneRule  `yaml:"clone_rules,omitempty"` // Ordered rules for clone path, first match wins
-	GitHub        GitHubConfig `yaml:"github"`
+	RepoRoots         []string     `yaml:"repo_roots"`
+	CloneRoot         string       `yaml:"clone_root"`
+	UseCloneRules     bool         `yaml:"use_clone_rules"`              // Enable regex-based clone path rules
+	CloneRules        []CloneRule  `yaml:"clone_rules,omitempty"`        // Ordered rules for clone path, first match wins
+	Clone             CloneOptions `yaml:"clone,omitempty"`              // git clone settings, overridable per clone rule
+	Hooks             Hooks        `yaml:"hooks,omitempty"`              // Lifecycle hook commands, extendable per clone rule
+	OpenStrategy      string       `yaml:"open_strategy,omitempty"`      // How enter opens a repo: editor (default), tmux_session or tmux_window
+	Editor            []string     `yaml:"editor,omitempty"`             // Editor argv replacing $EDITOR, see editorCommand
+	EditorRules       []EditorRule `yaml:"editor_rules,omitempty"`       // Per-repo editor overrides, first match wins
+	EditorIntegration string       `yaml:"editor_integration,omitempty"` // auto (default), none, neovim, emacs, vscode, zed or helix
+	Neovim            NeovimConfig `yaml:"neovim,omitempty"`             // Neovim integration settings
+	GitHub            GitHubConfig `yaml:"github"`
+	Sync              SyncConfig   `yaml:"sync,omitempty"` // Automatic sync schedule
 
 	// Filter settings - control which repos are displayed from cache
 	ShowOwner        bool `yaml:"show_owner"`        // Show repos owned by user (default true)
 	ShowCollaborator bool `yaml:"show_collaborator"` // Show repos user is collaborator on (default true)
 	ShowOrgMember    bool `yaml:"show_org_member"`   // Show repos from orgs user is member of (default true)
 	ShowLocal        bool `yaml:"show_local"`        // Show local-only repos (default true)
+	LocalClonesOnly  bool `yaml:"local_clones_only"` // Only show repos that are cloned locally (default false)
+	HideArchived     bool `yaml:"hide_archived"`     // Hide repos archived on GitHub (default false)
+
+	// Columns of the result list, in display order (empty = default layout)
+	Columns []ColumnConfig `yaml:"columns,omitempty"`
+
+	// User-defined command palette entries
+	Commands []CustomCommand `yaml:"commands,omitempty"`
 }
 
 func DefaultConfig() Config {
@@ -67,40 +184,158 @@ func DefaultConfig() Config {
 	}
 }
 
+// GetEditor returns the editor argv for a repo and whether it is a per-repo
+// override. Editor rules are checked first, then the matching clone rule,
+// then the global editor. An empty argv means $EDITOR.
+func (c Config) GetEditor(repo Repository) ([]string, bool) {
+	for _, rule := range c.EditorRules {
+		if rule.matches(repo) {
+			return rule.Editor, true
+		}
+	}
+	if rule, ok := c.matchCloneRule(repo.FullName); ok && len(rule.Editor) > 0 {
+		return rule.Editor, true
+	}
+	return c.Editor, false
+}
+
+// GetOpenStrategy returns how a repo is opened on enter
+func (c Config) GetOpenStrategy() string {
+	if c.OpenStrategy == "" {
+		return openEditor
+	}
+	return c.OpenStrategy
+}
+
 func (c Config) GetRepoRoots() []string {
 	return c.RepoRoots
 }
 
+// Filter returns the repo filter described by the config
+func (c Config) Filter() RepoFilter {
+	return RepoFilter{
+		ShowOwner:        c.ShowOwner,
+		ShowCollaborator: c.ShowCollaborator,
+		ShowOrgMember:    c.ShowOrgMember,
+		ShowLocal:        c.ShowLocal,
+		LocalClonesOnly:  c.LocalClonesOnly,
+		HideArchived:     c.HideArchived,
+	}
+}
+
+// SetFilter stores the filter settings in the config
+func (c *Config) SetFilter(f RepoFilter) {
+	c.ShowOwner = f.ShowOwner
+	c.ShowCollaborator = f.ShowCollaborator
+	c.ShowOrgMember = f.ShowOrgMember
+	c.ShowLocal = f.ShowLocal
+	c.LocalClonesOnly = f.LocalClonesOnly
+	c.HideArchived = f.HideArchived
+}
+
+// GetColumns returns the configured result columns, or the default layout
+func (c Config) GetColumns() []ColumnConfig {
+	if len(c.Columns) > 0 {
+		return c.Columns
+	}
+	return DefaultColumns()
+}
+
+// AutoSync reports whether due syncs start on their own, see SyncConfig
+func (c Config) AutoSync() bool {
+	return c.Sync.Auto == nil || *c.Sync.Auto
+}
+
+// GetRemoteSyncInterval returns how old the last GitHub sync may get before
+// the next one is due
+func (c Config) GetRemoteSyncInterval() time.Duration {
+	if c.Sync.RemoteInterval > 0 {
+		return c.Sync.RemoteInterval
+	}
+	return DefaultRemoteSyncInterval
+}
+
+// GetLocalScanInterval returns how old the last local scan may get before
+// the next one is due
+func (c Config) GetLocalScanInterval() time.Duration {
+	if c.Sync.LocalInterval > 0 {
+		return c.Sync.LocalInterval
+	}
+	return DefaultLocalScanInterval
+}
+
+// indexConfig returns the part of the config the index package uses
+func (c Config) indexConfig() index.Config {
+	rules := make([]index.CloneRule, len(c.CloneRules))
+	for i, rule := range c.CloneRules {
+		rules[i] = rule.CloneRule
+	}
+	return index.Config{
+		RepoRoots:     c.RepoRoots,
+		CloneRoot:     c.CloneRoot,
+		UseCloneRules: c.UseCloneRules,
+		CloneRules:    rules,
+		Clone:         c.Clone,
+	}
+}
+
+// localProvider and gitHubProvider are the repo sources of a sync
+func (c Config) localProvider() index.LocalProvider {
+	return index.LocalProvider{Roots: c.GetRepoRoots()}
+}
+
+func (c Config) gitHubProvider() index.GitHubProvider {
+	return index.GitHubProvider{Affiliation: c.GitHub.Affiliation}
+}
+
 func (c Config) GetCloneRoot() string {
-	if c.CloneRoot != "" {
-		return c.CloneRoot
+	return c.indexConfig().GetCloneRoot()
+}
+
+// compileRules compiles every clone and editor rule pattern once, so
+// matching doesn't recompile them on each GetClonePath or GetEditor call
+func (c *Config) compileRules() error {
+	for i := range c.CloneRules {
+		if err := c.CloneRules[i].Compile(); err != nil {
+			return fmt.Errorf("clone_rules[%d]: %w", i, err)
+		}
 	}
-	roots := c.GetRepoRoots()
-	if len(roots) > 0 {
-		return roots[0]
+	for i := range c.EditorRules {
+		if err := c.EditorRules[i].compile(); err != nil {
+			return fmt.Errorf("editor_rules[%d]: %w", i, err)
+		}
 	}
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, "repos")
+	return nil
 }
 
-// GetClonePath returns the full destination path for cloning a repo.
-// If UseCloneRules is enabled, it checks clone_rules in order and returns the first matching rule's path + repo name.
-// Falls back to clone_root + repo name if no rules match or UseCloneRules is disabled.
+// matchCloneRule returns the first clone rule matching fullName.
+// Returns false if UseCloneRules is disabled or no rule matches.
+func (c Config) matchCloneRule(fullName string) (CloneRule, bool) {
+	if i, _, ok := c.indexConfig().MatchCloneRule(fullName); ok {
+		return c.CloneRules[i], true
+	}
+	return CloneRule{}, false
+}
+
+// GetClonePath returns the full destination path for cloning a repo, see
+// index.Config.GetClonePath
 func (c Config) GetClonePath(fullName, repoName string) string {
-	if c.UseCloneRules {
-		for _, rule := range c.CloneRules {
-			re, err := regexp.Compile(rule.Pattern)
-			if err != nil {
-				// Invalid regex, skip this rule
-				continue
-			}
-			if re.MatchString(fullName) {
-				return filepath.Join(rule.Path, repoName)
-			}
-		}
+	return c.indexConfig().GetClonePath(fullName, repoName)
+}
+
+// GetCloneOptions returns the clone options for a repo: the global options
+// with those of the matching clone rule layered on top
+func (c Config) GetCloneOptions(fullName string) CloneOptions {
+	return c.indexConfig().GetCloneOptions(fullName)
+}
+
+// GetHooks returns the hooks for a repo: the global hooks followed by those
+// of the matching clone rule
+func (c Config) GetHooks(fullName string) Hooks {
+	if rule, ok := c.matchCloneRule(fullName); ok {
+		return c.Hooks.merge(rule.Hooks)
 	}
-	// No rules matched or UseCloneRules disabled, use default clone root
-	return filepath.Join(c.GetCloneRoot(), repoName)
+	return c.Hooks
 }
 
 func LoadConfig() (Config, error) {
@@ -131,6 +366,10 @@ func LoadConfig() (Config, error) {
 		return Config{}, fmt.Errorf("invalid config %s: %w", configPath, err)
 	}
 
+	if err := cfg.compileRules(); err != nil {
+		return Config{}, fmt.Errorf("invalid config %s: %w", configPath, err)
+	}
+
 	return cfg, nil
 }
 
@@ -159,30 +398,68 @@ func (c Config) Validate() error {
 		return errors.New("github.affiliation cannot be empty")
 	}
 
-	for _, root := range c.RepoRoots {
-		if !filepath.IsAbs(root) {
-			return fmt.Errorf("repo_roots must contain absolute paths (got %q)", root)
+	// Repo roots, clone root, clone rules and clone options
+	if err := c.indexConfig().Validate(); err != nil {
+		return err
+	}
+
+	for i, rule := range c.CloneRules {
+		if rule.Hooks != nil {
+			if err := rule.Hooks.validate(fmt.Sprintf("clone_rules[%d].hooks", i)); err != nil {
+				return err
+			}
+		}
+		if err := validateEditorArgv(fmt.Sprintf("clone_rules[%d].editor", i), rule.Editor); err != nil {
+			return err
 		}
 	}
 
-	if c.CloneRoot != "" && !filepath.IsAbs(c.CloneRoot) {
-		return fmt.Errorf("clone_root must be an absolute path (got %q)", c.CloneRoot)
+	if err := c.Hooks.validate("hooks"); err != nil {
+		return err
 	}
 
-	// Validate clone rules
-	for i, rule := range c.CloneRules {
-		if rule.Pattern == "" {
-			return fmt.Errorf("clone_rules[%d]: pattern cannot be empty", i)
+	if err := c.Sync.validate("sync"); err != nil {
+		return err
+	}
+
+	if err := validateEditorArgv("editor", c.Editor); err != nil {
+		return err
+	}
+	for i, rule := range c.EditorRules {
+		if len(rule.Editor) == 0 {
+			return fmt.Errorf("editor_rules[%d].editor cannot be empty", i)
 		}
-		if _, err := regexp.Compile(rule.Pattern); err != nil {
-			return fmt.Errorf("clone_rules[%d]: invalid regex pattern %q: %v", i, rule.Pattern, err)
+		if err := rule.validate(fmt.Sprintf("editor_rules[%d]", i)); err != nil {
+			return err
 		}
-		if rule.Path == "" {
-			return fmt.Errorf("clone_rules[%d]: path cannot be empty", i)
+	}
+
+	if s := c.EditorIntegration; s != "" && !slices.Contains(integrationNames(), s) {
+		return fmt.Errorf("editor_integration must be one of %s (got %q)", strings.Join(integrationNames(), ", "), s)
+	}
+
+	if s := c.Neovim.OpenStrategy; s != "" && !slices.Contains(neovimOpenStrategies, s) {
+		return fmt.Errorf("neovim.open_strategy must be one of %s (got %q)", strings.Join(neovimOpenStrategies, ", "), s)
+	}
+
+	if c.OpenStrategy != "" && !slices.Contains(openStrategies, c.OpenStrategy) {
+		return fmt.Errorf("open_strategy must be one of %s (got %q)", strings.Join(openStrategies, ", "), c.OpenStrategy)
+	}
+
+	if err := validateColumns(c.Columns); err != nil {
+		return err
+	}
+
+	keys := make(map[string]bool)
+	for i, cmd := range c.Commands {
+		field := fmt.Sprintf("commands[%d]", i)
+		if err := cmd.validate(field); err != nil {
+			return err
 		}
-		if !filepath.IsAbs(rule.Path) {
-			return fmt.Errorf("clone_rules[%d]: path must be absolute (got %q)", i, rule.Path)
+		if keys[cmd.Key] {
+			return fmt.Errorf("%s.key %q is used by another command", field, cmd.Key)
 		}
+		keys[cmd.Key] = true
 	}
 
 	return nil
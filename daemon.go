@@ -0,0 +1,492 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/wealthystudent/fuzzyrepo/index"
+)
+
+const (
+	daemonTickInterval   = time.Minute      // How often sync schedules and files are checked
+	daemonStatusInterval = 5 * time.Minute  // How often git status of local clones is reread
+	daemonRetryInterval  = 30 * time.Minute // Pause of scheduled remote syncs after a failed one
+)
+
+// daemon keeps the index, usage data and git status of local clones in
+// memory, schedules syncs and answers clients on a unix socket. Clients fall
+// back to the cache files when it isn't running, so it is optional.
+type daemon struct {
+	ctx       context.Context
+	stop      context.CancelFunc
+	startedAt time.Time
+
+	mu          sync.Mutex
+	config      Config
+	repos       []Repository
+	usage       UsageData
+	localStatus map[string]LocalStatus
+	statusAt    time.Time
+
+	// Modification times of the files last loaded, to pick up other writers
+	configMtime time.Time
+	cacheMtime  time.Time
+	usageMtime  time.Time
+
+	running        *syncRun // The sync in progress, nil if none
+	queued         *syncRun // Remote sync requested during a local scan
+	remoteFailedAt time.Time
+	lastErr        string
+}
+
+// syncRun is one sync of the daemon; err is set when done is closed
+type syncRun struct {
+	remote bool
+	done   chan struct{}
+	err    error
+}
+
+// runDaemonCommand implements "fuzzyrepo daemon [status|stop]" and returns
+// the exit code
+func runDaemonCommand(args []string) int {
+	switch {
+	case len(args) == 0:
+		return runDaemon()
+	case len(args) == 1 && args[0] == "status":
+		var status daemonStatus
+		if err := daemonCall("status", nil, &status); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		printDaemonStatus(status)
+		return 0
+	case len(args) == 1 && args[0] == "stop":
+		if err := daemonCall("shutdown", nil, nil); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintln(os.Stderr, "usage: fuzzyrepo daemon [status|stop]")
+	return 2
+}
+
+func printDaemonStatus(s daemonStatus) {
+	state := "idle"
+	if s.Syncing {
+		state = "syncing"
+	}
+	fmt.Printf("pid %d, up since %s, %s\n", s.PID, s.StartedAt.Format(time.DateTime), state)
+	fmt.Printf("%d repos, remote sync %s, local scan %s\n", s.Repos, syncAge(s.LastRemoteSync), syncAge(s.LastLocalScan))
+	if s.LastError != "" {
+		fmt.Println("last error:", s.LastError)
+	}
+}
+
+// runDaemon serves the socket until interrupted or stopped by a client
+func runDaemon() int {
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not load config:", err)
+		return 1
+	}
+
+	socketPath := getDaemonSocketPath()
+	if err := os.MkdirAll(getCacheDir(), 0o755); err != nil {
+		fmt.Fprintln(os.Stderr, "could not create cache dir:", err)
+		return 1
+	}
+	// The daemon holds daemon.lock as long as it runs, so two daemons
+	// started at once can't both remove and bind the socket
+	lock, err := index.TryLockFile(getDaemonLockPath())
+	if errors.Is(err, index.ErrLocked) {
+		fmt.Fprintln(os.Stderr, "daemon is already running")
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not lock daemon:", err)
+		return 1
+	}
+	defer lock.Unlock()
+
+	// No daemon holds the lock, so a leftover socket is stale
+	_ = os.Remove(socketPath)
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not listen:", err)
+		return 1
+	}
+	_ = os.Chmod(socketPath, 0o600)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	d := &daemon{
+		ctx:         ctx,
+		stop:        stop,
+		startedAt:   time.Now(),
+		config:      config,
+		localStatus: make(map[string]LocalStatus),
+	}
+	d.mu.Lock()
+	d.reloadIfChanged()
+	d.mu.Unlock()
+
+	go d.schedule()
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	fmt.Fprintln(os.Stderr, "fuzzyrepo daemon listening on", socketPath)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		go d.serve(conn)
+	}
+
+	_ = os.Remove(socketPath)
+	return 0
+}
+
+// fileMtime returns the modification time of path, zero if it's missing
+func fileMtime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// reloadIfChanged rereads the config, cache and usage files when another
+// process changed them. The caller holds d.mu.
+func (d *daemon) reloadIfChanged() {
+	configMtime := fileMtime(xdgConfigPath())
+	if legacy := fileMtime(legacyConfigPath()); legacy.After(configMtime) {
+		configMtime = legacy
+	}
+	if !configMtime.Equal(d.configMtime) {
+		// A broken config keeps the last good one
+		if config, err := LoadConfig(); err == nil {
+			d.config = config
+		}
+		d.configMtime = configMtime
+	}
+
+	if mtime := GetCacheMtime(); !mtime.Equal(d.cacheMtime) || d.repos == nil {
+		if repos, err := loadRepoCache(); err == nil {
+			d.repos = repos
+		}
+		d.cacheMtime = mtime
+	}
+
+	if mtime := fileMtime(getUsagePath()); !mtime.Equal(d.usageMtime) || d.usage == nil {
+		if usage, err := LoadUsage(); err == nil {
+			d.usage = usage
+		}
+		d.usageMtime = mtime
+	}
+}
+
+// schedule runs due syncs and keeps git status fresh until the daemon stops.
+// It replaces the startup checks and detached syncs of standalone runs.
+func (d *daemon) schedule() {
+	ticker := time.NewTicker(daemonTickInterval)
+	defer ticker.Stop()
+
+	for {
+		d.tick()
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *daemon) tick() {
+	d.mu.Lock()
+	d.reloadIfChanged()
+	retryRemote := time.Since(d.remoteFailedAt) > daemonRetryInterval
+	hasRoots := len(d.config.GetRepoRoots()) > 0
+	// Like startup checks, an empty index is filled even without auto sync
+	autoSync := d.config.AutoSync() || len(d.repos) == 0
+	remoteInterval := d.config.GetRemoteSyncInterval()
+	localInterval := d.config.GetLocalScanInterval()
+	statusStale := time.Since(d.statusAt) > daemonStatusInterval
+	d.mu.Unlock()
+
+	meta, _ := LoadMetadata()
+	switch {
+	case !autoSync:
+	case retryRemote && IsRemoteSyncDue(meta, remoteInterval):
+		d.startSync(true)
+	case hasRoots && IsLocalScanDue(meta, localInterval):
+		d.startSync(false)
+	}
+
+	if statusStale {
+		d.refreshLocalStatus()
+	}
+}
+
+// startSync starts a remote sync (or a local scan) unless one is running,
+// and returns the run to wait for. A running remote sync also serves a
+// local scan request, but a running local scan doesn't fetch GitHub: a
+// remote sync requested meanwhile is queued to start after it.
+func (d *daemon) startSync(remote bool) *syncRun {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.running != nil {
+		if !remote || d.running.remote {
+			return d.running
+		}
+		if d.queued == nil {
+			d.queued = &syncRun{remote: true, done: make(chan struct{})}
+		}
+		return d.queued
+	}
+
+	run := &syncRun{remote: remote, done: make(chan struct{})}
+	d.begin(run)
+	return run
+}
+
+// begin runs run in the background, then the queued run if there is one.
+// The caller holds d.mu.
+func (d *daemon) begin(run *syncRun) {
+	d.running = run
+
+	go func() {
+		err := d.sync(run.remote)
+
+		d.mu.Lock()
+		switch {
+		case errors.Is(err, ErrSyncRunning):
+			// Another process syncs, which is no reason to back off
+		case err != nil:
+			d.lastErr = err.Error()
+			if run.remote {
+				d.remoteFailedAt = time.Now()
+			}
+		default:
+			d.lastErr = ""
+			if run.remote {
+				d.remoteFailedAt = time.Time{}
+			}
+		}
+		run.err = err
+		d.running = nil
+		if next := d.queued; next != nil {
+			d.queued = nil
+			d.begin(next)
+		}
+		d.mu.Unlock()
+		close(run.done)
+
+		if err == nil {
+			d.refreshLocalStatus()
+		}
+	}()
+}
+
+func (d *daemon) sync(remote bool) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	repos, err := runSync(d.ctx, config, remote, nopReporter{})
+	if err != nil {
+		return err
+	}
+
+	for i := range repos {
+		repos[i].ComputeSearchText()
+	}
+	d.mu.Lock()
+	d.config = config
+	d.repos = repos
+	d.cacheMtime = GetCacheMtime()
+	d.mu.Unlock()
+	return nil
+}
+
+// refreshLocalStatus rereads the git status of every local clone
+func (d *daemon) refreshLocalStatus() {
+	d.mu.Lock()
+	var paths []string
+	for _, r := range d.repos {
+		if r.ExistsLocal && r.LocalPath != "" {
+			paths = append(paths, r.LocalPath)
+		}
+	}
+	d.mu.Unlock()
+
+	status := loadLocalStatus(paths)
+
+	d.mu.Lock()
+	d.localStatus = status
+	d.statusAt = time.Now()
+	d.mu.Unlock()
+}
+
+// serve answers the requests of one client, one JSON object per line
+func (d *daemon) serve(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var resp daemonResponse
+		var req daemonRequest
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp.Error = "invalid request: " + err.Error()
+		} else if result, err := d.handle(req); err != nil {
+			resp.Error = err.Error()
+		} else if resp.Result, err = json.Marshal(result); err != nil {
+			resp.Error = err.Error()
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+		// Stop once the client got its answer
+		if req.Method == "shutdown" {
+			d.stop()
+			return
+		}
+	}
+}
+
+// decodeParams unmarshals the params of req into v; missing params leave v as is
+func decodeParams(req daemonRequest, v any) error {
+	if len(req.Params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		return fmt.Errorf("invalid params: %v", err)
+	}
+	return nil
+}
+
+func (d *daemon) handle(req daemonRequest) (any, error) {
+	switch req.Method {
+	case "status":
+		return d.status(), nil
+
+	case "list", "search":
+		var p listParams
+		if err := decodeParams(req, &p); err != nil {
+			return nil, err
+		}
+		if req.Method == "list" {
+			p.Query = ""
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.reloadIfChanged()
+		repos, err := queryRepos(d.repos, d.usage, d.config, p)
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]repoEntry, len(repos))
+		for i, r := range repos {
+			entries[i] = newRepoEntry(r, d.usage, d.localStatus)
+		}
+		return entries, nil
+
+	case "get":
+		var p repoParams
+		if err := decodeParams(req, &p); err != nil {
+			return nil, err
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.reloadIfChanged()
+		for _, r := range d.repos {
+			if strings.EqualFold(r.FullName, p.FullName) {
+				return newRepoEntry(r, d.usage, d.localStatus), nil
+			}
+		}
+		return nil, fmt.Errorf("%s is not in the repo cache", p.FullName)
+
+	case "record_usage":
+		var p repoParams
+		if err := decodeParams(req, &p); err != nil {
+			return nil, err
+		}
+		if p.FullName == "" {
+			return nil, errors.New("full_name is required")
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.reloadIfChanged()
+		// Record in the saved history, which other processes may have
+		// changed since it was loaded
+		err := cacheStore().UpdateUsage(func(usage UsageData) error {
+			usage.Record(p.FullName)
+			d.usage = usage
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		d.usageMtime = fileMtime(getUsagePath())
+		return nil, nil
+
+	case "local_status":
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		return d.localStatus, nil
+
+	case "refresh":
+		var p refreshParams
+		if err := decodeParams(req, &p); err != nil {
+			return nil, err
+		}
+		run := d.startSync(p.Remote)
+		if p.Wait {
+			<-run.done
+			if run.err != nil {
+				return nil, run.err
+			}
+		}
+		return d.status(), nil
+
+	case "shutdown":
+		// Stopped by serve after answering
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unknown method %q", req.Method)
+}
+
+func (d *daemon) status() daemonStatus {
+	meta, _ := LoadMetadata()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return daemonStatus{
+		PID:            os.Getpid(),
+		StartedAt:      d.startedAt,
+		Repos:          len(d.repos),
+		Syncing:        d.running != nil,
+		LastRemoteSync: meta.LastRemoteSync,
+		LastLocalScan:  meta.LastLocalScan,
+		LastError:      d.lastErr,
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"time"
+)
+
+const (
+	daemonDialTimeout = 200 * time.Millisecond
+	daemonCallTimeout = 10 * time.Second
+)
+
+var (
+	ErrNoDaemon     = errors.New("daemon is not running")
+	ErrDaemonFailed = errors.New("daemon request failed")
+)
+
+// getDaemonSocketPath returns the unix socket the daemon listens on
+func getDaemonSocketPath() string {
+	return filepath.Join(getCacheDir(), "daemon.sock")
+}
+
+// getDaemonLockPath returns the file a running daemon holds locked
+func getDaemonLockPath() string {
+	return filepath.Join(getCacheDir(), "daemon.lock")
+}
+
+// daemonRequest is one line of the daemon protocol: a method and its params
+type daemonRequest struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// daemonResponse answers a request with a result or an error message
+type daemonResponse struct {
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// listParams are the params of "list" and "search"
+type listParams struct {
+	Query string `json:"query,omitempty"`
+	Sort  string `json:"sort,omitempty"`  // Sort mode, default frecency
+	Limit int    `json:"limit,omitempty"` // 0 = all
+	All   bool   `json:"all,omitempty"`   // Ignore the config filters
+}
+
+// repoParams are the params of "get" and "record_usage"
+type repoParams struct {
+	FullName string `json:"full_name"`
+}
+
+// refreshParams are the params of "refresh"
+type refreshParams struct {
+	Remote bool `json:"remote,omitempty"` // Sync GitHub too, not only local roots
+	Wait   bool `json:"wait,omitempty"`   // Answer when the sync finished
+}
+
+// daemonStatus is the result of "status"
+type daemonStatus struct {
+	PID            int       `json:"pid"`
+	StartedAt      time.Time `json:"started_at"`
+	Repos          int       `json:"repos"`
+	Syncing        bool      `json:"syncing"`
+	LastRemoteSync time.Time `json:"last_remote_sync,omitzero"`
+	LastLocalScan  time.Time `json:"last_local_scan,omitzero"`
+	LastError      string    `json:"last_error,omitempty"`
+}
+
+// daemonClient is a connection to a running fuzzyrepo daemon
+type daemonClient struct {
+	conn    net.Conn
+	dec     *json.Decoder
+	timeout time.Duration
+}
+
+// dialDaemon connects to the daemon, failing fast with ErrNoDaemon when
+// none is running
+func dialDaemon() (*daemonClient, error) {
+	conn, err := net.DialTimeout("unix", getDaemonSocketPath(), daemonDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNoDaemon, err)
+	}
+	return &daemonClient{
+		conn:    conn,
+		dec:     json.NewDecoder(bufio.NewReader(conn)),
+		timeout: daemonCallTimeout,
+	}, nil
+}
+
+func (c *daemonClient) Close() error {
+	return c.conn.Close()
+}
+
+// call sends a request and decodes its result into result (if not nil)
+func (c *daemonClient) call(method string, params, result any) error {
+	req := daemonRequest{Method: method}
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrDaemonFailed, method, err)
+		}
+		req.Params = b
+	}
+
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrDaemonFailed, method, err)
+	}
+	if err := json.NewEncoder(c.conn).Encode(req); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrDaemonFailed, method, err)
+	}
+
+	var resp daemonResponse
+	if err := c.dec.Decode(&resp); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrDaemonFailed, method, err)
+	}
+	if resp.Error != "" {
+		return fmt.Errorf("%w: %s: %s", ErrDaemonFailed, method, resp.Error)
+	}
+	if result != nil && resp.Result != nil {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrDaemonFailed, method, err)
+		}
+	}
+	return nil
+}
+
+// daemonCall runs a single request on a new connection. Without a daemon
+// it returns ErrNoDaemon, so callers can fall back to reading the cache.
+func daemonCall(method string, params, result any) error {
+	c, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.call(method, params, result)
+}
+
+// daemonRunning reports whether a daemon answers on the socket
+func daemonRunning() bool {
+	return daemonCall("status", nil, nil) == nil
+}
+
+// daemonRepos returns the whole index of the daemon, unfiltered
+func daemonRepos() ([]Repository, error) {
+	var repos []Repository
+	if err := daemonCall("list", listParams{All: true}, &repos); err != nil {
+		return nil, err
+	}
+	for i := range repos {
+		repos[i].ComputeSearchText()
+	}
+	return repos, nil
+}
+
+// daemonRefresh asks the daemon for a sync and waits until it finished
+func daemonRefresh(remote bool) error {
+	c, err := dialDaemon()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	// A remote sync takes as long as GitHub needs
+	c.timeout = 10 * time.Minute
+	return c.call("refresh", refreshParams{Remote: remote, Wait: true}, nil)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// EditorRule picks the editor for repos matching a pattern, a language or both
+type EditorRule struct {
+	Pattern  string   `yaml:"pattern,omitempty"`  // Regex matched against full_name (owner/repo)
+	Language string   `yaml:"language,omitempty"` // GitHub primary language, case-insensitive
+	Editor   []string `yaml:"editor"`             // Editor argv, see editorCommand
+
+	re *regexp.Regexp // Compiled Pattern, set by compile
+}
+
+// compile compiles the pattern once, so matching doesn't recompile it
+func (r *EditorRule) compile() error {
+	if r.Pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regex pattern %q: %v", r.Pattern, err)
+	}
+	r.re = re
+	return nil
+}
+
+// matches reports whether the rule applies to repo. A rule that was not
+// compiled compiles its pattern on demand; an invalid pattern never matches.
+func (r EditorRule) matches(repo Repository) bool {
+	if r.Language != "" && !strings.EqualFold(r.Language, repo.Language) {
+		return false
+	}
+	if r.Pattern == "" {
+		return true
+	}
+	if r.re == nil && r.compile() != nil {
+		return false
+	}
+	return r.re.MatchString(repo.FullName)
+}
+
+// validate checks the rule; field is the config key used in errors
+func (r EditorRule) validate(field string) error {
+	if r.Pattern == "" && r.Language == "" {
+		return fmt.Errorf("%s needs a pattern or a language", field)
+	}
+	if err := r.compile(); err != nil {
+		return fmt.Errorf("%s: %w", field, err)
+	}
+	return validateEditorArgv(field+".editor", r.Editor)
+}
+
+// validateEditorArgv checks an editor argv; an empty one is valid and means "not set"
+func validateEditorArgv(field string, argv []string) error {
+	if len(argv) == 0 {
+		return nil
+	}
+	if strings.TrimSpace(argv[0]) == "" {
+		return fmt.Errorf("%s: the first element must be the editor command", field)
+	}
+	for _, arg := range argv {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(arg, -1) {
+			if !slices.Contains(commandPlaceholders, m[1]) {
+				return fmt.Errorf("%s: unknown placeholder {%s} (use {%s})", field, m[1], strings.Join(commandPlaceholders, "}, {"))
+			}
+		}
+	}
+	return nil
+}
+
+// editorCommand builds the process that opens path with the editor argv.
+// Arguments may use the custom command placeholders, with {path} being the
+// opened path. Without a {path} argument the path is appended, so
+// ["code", "-w"] runs "code -w <path>". No shell is involved, so repo names
+// and paths can't inject anything.
+func editorCommand(argv []string, path string, repo Repository) *exec.Cmd {
+	repo.LocalPath = path
+
+	args := make([]string, 0, len(argv)+1)
+	hasPath := false
+	for _, arg := range argv[1:] {
+		if strings.Contains(arg, "{path}") {
+			hasPath = true
+		}
+		args = append(args, expandCommandTemplate(arg, repo))
+	}
+	if !hasPath {
+		args = append(args, path)
+	}
+
+	return exec.Command(expandCommandTemplate(argv[0], repo), args...)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEditorRulesCompiledOnLoad(t *testing.T) {
+	writeTestConfig(t, `
+editor_rules:
+  - pattern: ^acme/
+    editor: [code]
+`)
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.EditorRules[0].re == nil {
+		t.Error("editor rule pattern was not compiled on load")
+	}
+	if argv, ok := config.GetEditor(Repository{FullName: "acme/api"}); !ok || !slices.Equal(argv, []string{"code"}) {
+		t.Errorf("GetEditor(acme/api) = %v, %v, want the rule's editor", argv, ok)
+	}
+	if _, ok := config.GetEditor(Repository{FullName: "other/api"}); ok {
+		t.Error("GetEditor(other/api) matched the rule")
+	}
+}
+
+func TestEditorRuleInvalidPattern(t *testing.T) {
+	writeTestConfig(t, `
+editor_rules:
+  - pattern: "acme/(api"
+    editor: [code]
+`)
+	if _, err := LoadConfig(); err == nil {
+		t.Error("LoadConfig accepted an editor rule with an invalid pattern")
+	}
+}
+
+func TestEnvEditor(t *testing.T) {
+	tests := []struct {
+		editor string
+		want   []string // Nil for an error
+	}{
+		{"vim", []string{"vim"}},
+		{"code -w", []string{"code", "-w"}},
+		{"emacsclient -t -a ''", []string{"emacsclient", "-t", "-a", ""}},
+		{`"/opt/My Editor/bin/edit" --wait`, []string{"/opt/My Editor/bin/edit", "--wait"}},
+		{"nvim -c 'set ft=go'", []string{"nvim", "-c", "set ft=go"}},
+		{"vim 'unterminated", nil},
+		{"vim; rm -rf ~", nil},
+	}
+	for _, tt := range tests {
+		t.Setenv("EDITOR", tt.editor)
+		got, err := envEditor()
+		if tt.want == nil {
+			if err == nil {
+				t.Errorf("envEditor(%q) = %q, want an error", tt.editor, got)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("envEditor(%q) = %q, %v, want %q", tt.editor, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// filterToggle is one entry of the filter overlay
+type filterToggle struct {
+	key     string
+	name    string
+	summary string // Shown in the status line when the toggle hides repos
+	field   func(f *RepoFilter) *bool
+	hides   bool // True if enabling the toggle hides repos, false if disabling does
+}
+
+var filterToggles = []filterToggle{
+	{key: "o", name: "show owned", summary: "no owned", hides: false,
+		field: func(f *RepoFilter) *bool { return &f.ShowOwner }},
+	{key: "c", name: "show collaborator", summary: "no collaborator", hides: false,
+		field: func(f *RepoFilter) *bool { return &f.ShowCollaborator }},
+	{key: "g", name: "show org member", summary: "no org", hides: false,
+		field: func(f *RepoFilter) *bool { return &f.ShowOrgMember }},
+	{key: "l", name: "show local only", summary: "no local-only", hides: false,
+		field: func(f *RepoFilter) *bool { return &f.ShowLocal }},
+	{key: "k", name: "local clones only", summary: "clones only", hides: true,
+		field: func(f *RepoFilter) *bool { return &f.LocalClonesOnly }},
+	{key: "a", name: "hide archived", summary: "no archived", hides: true,
+		field: func(f *RepoFilter) *bool { return &f.HideArchived }},
+}
+
+// toggleFilter flips a session filter and re-applies it instantly
+func (m *Model) toggleFilter(t filterToggle) {
+	v := t.field(&m.filter)
+	*v = !*v
+	m.all = filterRepos(m.cache, m.filter)
+	m.applySearch()
+}
+
+// saveFilters persists the session filters to the config file
+func (m *Model) saveFilters() {
+	cfg := m.config
+	cfg.SetFilter(m.filter)
+	if err := SaveConfig(cfg); err != nil {
+		m.setMessage(fmt.Sprintf("could not save filters: %v", err), ErrorLevel)
+		return
+	}
+	m.config = cfg
+	m.setMessage("filters saved to config", InfoLevel)
+}
+
+// filterSummary describes the filters that currently hide repos, or "" if none do
+func (m Model) filterSummary() string {
+	var active []string
+	for _, t := range filterToggles {
+		if *t.field(&m.filter) == t.hides {
+			active = append(active, t.summary)
+		}
+	}
+	if len(active) == 0 {
+		return ""
+	}
+
+	summary := "filters: " + strings.Join(active, ", ")
+	if m.filter != m.config.Filter() {
+		summary += " (unsaved)"
+	}
+	return summary
+}
+
+func (m Model) updateFilters(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeySpace:
+		m.showFilters = false
+		return m, nil
+
+	case tea.KeyUp:
+		if m.filterCursor > 0 {
+			m.filterCursor--
+		}
+		return m, nil
+
+	case tea.KeyDown:
+		if m.filterCursor < len(filterToggles)-1 {
+			m.filterCursor++
+		}
+		return m, nil
+
+	case tea.KeyEnter:
+		m.toggleFilter(filterToggles[m.filterCursor])
+		return m, nil
+
+	default:
+		if msg.Type == tea.KeyRunes {
+			key := msg.String()
+			if key == "w" {
+				m.saveFilters()
+				m.showFilters = false
+				return m, nil
+			}
+			for i, t := range filterToggles {
+				if t.key == key {
+					m.filterCursor = i
+					m.toggleFilter(t)
+					return m, nil
+				}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+func (m Model) buildFilterBox() string {
+	keyStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#555555")).
+		Background(bgColor).
+		Width(3)
+
+	nameStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#666666")).
+		Background(bgColor)
+
+	selectedKeyStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#ffffff")).
+		Background(bgColor).
+		Width(3)
+
+	selectedNameStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#ffffff")).
+		Background(bgColor)
+
+	var lines []string
+	lines = append(lines, inputTextStyle.Render("Filters"))
+
+	for i, t := range filterToggles {
+		mark := "[ ]"
+		if *t.field(&m.filter) {
+			mark = "[x]"
+		}
+		if i == m.filterCursor {
+			lines = append(lines, selectedKeyStyle.Render(t.key)+bgOnlyStyle.Render(" ")+selectedNameStyle.Render(mark+" "+t.name))
+		} else {
+			lines = append(lines, keyStyle.Render(t.key)+bgOnlyStyle.Render(" ")+nameStyle.Render(mark+" "+t.name))
+		}
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, dimStyle.Render(fmt.Sprintf("%d of %d repos shown", len(m.all), len(m.cache))))
+	lines = append(lines, "")
+	lines = append(lines, keybindStyle.Render("enter toggle  w save to config  esc close"))
+
+	return overlayStyle.Render(strings.Join(lines, "\n"))
+}
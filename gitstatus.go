@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"sync"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// LocalStatus is the working tree state of a local clone
+type LocalStatus struct {
+	Branch string `json:"branch"`
+	Dirty  bool   `json:"dirty"`
+}
+
+// localStatusMsg carries freshly read git status, keyed by local path
+type localStatusMsg map[string]LocalStatus
+
+// localStatusWorkers bounds the number of concurrent git processes
+const localStatusWorkers = 8
+
+// readLocalStatus runs `git status` in the given repo and parses the branch
+// header and whether any tracked file is modified
+func readLocalStatus(path string) (LocalStatus, error) {
+	cmd := exec.Command("git", "-C", path, "status", "--porcelain", "--branch", "--untracked-files=no")
+	out, err := cmd.Output()
+	if err != nil {
+		return LocalStatus{}, err
+	}
+
+	var status LocalStatus
+	for _, line := range strings.Split(string(out), "\n") {
+		if line == "" {
+			continue
+		}
+		if branch, ok := strings.CutPrefix(line, "## "); ok {
+			status.Branch = parseBranchHeader(branch)
+			continue
+		}
+		status.Dirty = true
+	}
+	return status, nil
+}
+
+// parseBranchHeader extracts the branch name from a porcelain branch header
+// such as "main...origin/main [ahead 1]" or "HEAD (no branch)"
+func parseBranchHeader(header string) string {
+	if name, ok := strings.CutPrefix(header, "No commits yet on "); ok {
+		return name
+	}
+	if strings.HasPrefix(header, "HEAD (no branch)") {
+		return "(detached)"
+	}
+	if i := strings.Index(header, "..."); i >= 0 {
+		return header[:i]
+	}
+	if i := strings.Index(header, " "); i >= 0 {
+		return header[:i]
+	}
+	return header
+}
+
+// loadLocalStatus reads the git status of every given path in parallel.
+// Paths that fail (missing dir, not a repo) are left out of the result.
+func loadLocalStatus(paths []string) map[string]LocalStatus {
+	result := make(map[string]LocalStatus, len(paths))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	jobs := make(chan string)
+	for i := 0; i < localStatusWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				status, err := readLocalStatus(path)
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				result[path] = status
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, path := range paths {
+		jobs <- path
+	}
+	close(jobs)
+	wg.Wait()
+
+	return result
+}
+
+// loadLocalStatusCmd returns a command that reads git status for all local
+// repos in the background, or nil if there is nothing to read
+func loadLocalStatusCmd(repos []Repository) tea.Cmd {
+	var paths []string
+	for _, r := range repos {
+		if r.ExistsLocal && r.LocalPath != "" {
+			paths = append(paths, r.LocalPath)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	return func() tea.Msg {
+		// A running daemon already has it
+		var status map[string]LocalStatus
+		if err := daemonCall("local_status", nil, &status); err == nil && len(status) > 0 {
+			return localStatusMsg(status)
+		}
+		return localStatusMsg(loadLocalStatus(paths))
+	}
+}
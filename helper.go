@@ -1,14 +1,15 @@
 package main
 
 import (
-	"context"
-	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/wealthystudent/fuzzyrepo/index"
 )
 
 func getHomeDir() string {
@@ -19,17 +20,64 @@ func getHomeDir() string {
 	return home
 }
 
+// padOrTrim pads or truncates s to exactly w terminal cells.
+// Widths are measured in display cells, so wide characters are handled.
 func padOrTrim(s string, w int) string {
 	if w <= 0 {
 		return ""
 	}
-	if len(s) > w {
-		if w <= 1 {
-			return s[:w]
-		}
-		return s[:w-1] + "…"
+	if ansi.StringWidth(s) > w {
+		s = ansi.Truncate(s, w, "…")
+	}
+	return s + strings.Repeat(" ", w-ansi.StringWidth(s))
+}
+
+// abbreviateHome replaces the home directory prefix of path with ~
+func abbreviateHome(path string) string {
+	home := getHomeDir()
+	if home == "" || path == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rest
+	}
+	return path
+}
+
+// formatAge renders the time since t in a compact form like "3d" or "5mo"
+func formatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d < 14*24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d < 60*24*time.Hour:
+		return fmt.Sprintf("%dw", int(d.Hours()/(24*7)))
+	case d < 365*24*time.Hour:
+		return fmt.Sprintf("%dmo", int(d.Hours()/(24*30)))
+	default:
+		return fmt.Sprintf("%dy", int(d.Hours()/(24*365)))
+	}
+}
+
+// formatCount renders large counts compactly, e.g. 1234 -> "1.2k"
+func formatCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
 	}
-	return s + strings.Repeat(" ", w-len(s))
 }
 
 func clamp(v, lo, hi int) int {
@@ -43,121 +91,20 @@ func clamp(v, lo, hi int) int {
 }
 
 func getCacheDir() string {
-	return filepath.Join(getHomeDir(), ".local", "share", "fuzzyrepo")
+	return index.DefaultDir()
 }
 
-func getCachePath() string {
-	return filepath.Join(getCacheDir(), "repos.json")
+// cacheStore returns the index files in the cache dir
+func cacheStore() index.Store {
+	return index.Store{Dir: getCacheDir()}
 }
 
-func progressiveRefresh(config Config, uiMsgs chan<- tea.Msg) {
-	ctx := context.Background()
-	cacheDir := getCacheDir()
-	path := getCachePath()
-
-	_ = os.MkdirAll(cacheDir, 0o755)
-
-	localRepos := indexLocalRepos(config.GetRepoRoots())
-
-	meta, _ := LoadMetadata()
-	remoteRepos, _ := loadRepoCache()
-	if len(remoteRepos) == 0 && meta.RemoteSyncPID != 0 {
-		remoteRepos = nil
-	}
-
-	mergedLocalFirst := mergeRepos(localRepos, remoteRepos)
-	uiMsgs <- localReposUpdatedMsg(mergedLocalFirst)
-	uiMsgs <- localRefreshDoneMsg{}
-
-	githubClient, err := getGithubClient(ctx)
-	if err != nil {
-		uiMsgs <- errorMsg{err: err}
-		uiMsgs <- refreshFinishedMsg{}
-		return
-	}
-
-	remoteRepos, err = getRemoteRepositories(ctx, githubClient, config)
-	if err != nil {
-		uiMsgs <- errorMsg{err: err}
-		uiMsgs <- refreshFinishedMsg{}
-		return
-	}
-
-	merged := mergeRepos(localRepos, remoteRepos)
-
-	uiMsgs <- reposUpdatedMsg(merged)
-
-	b, err := json.MarshalIndent(merged, "", "  ")
-	if err == nil {
-		tmpPath := path + ".tmp"
-		if err := os.WriteFile(tmpPath, b, 0o600); err == nil {
-			_ = os.Rename(tmpPath, path)
-		}
-	}
-
-	// Update metadata with sync timestamps
-	meta, _ = LoadMetadata()
-	meta.UpdateRemoteSyncTime()
-	meta.UpdateLocalScanTime()
-	_ = SaveMetadata(meta)
-
-	uiMsgs <- refreshFinishedMsg{}
-}
-
-func mergeRepos(local, remote []Repository) []Repository {
-	repoMap := make(map[string]Repository)
-
-	for _, r := range remote {
-		key := strings.ToLower(r.FullName)
-		repoMap[key] = r
-	}
-
-	for _, r := range local {
-		key := strings.ToLower(r.FullName)
-		if existing, ok := repoMap[key]; ok {
-			existing.LocalPath = r.LocalPath
-			existing.ExistsLocal = true
-			existing.ComputeSearchText()
-			repoMap[key] = existing
-		} else {
-			repoMap[key] = r
-		}
-	}
-
-	result := make([]Repository, 0, len(repoMap))
-	for _, r := range repoMap {
-		result = append(result, r)
-	}
-
-	return result
+func getCachePath() string {
+	return cacheStore().ReposPath()
 }
 
 func loadRepoCache() ([]Repository, error) {
-	cacheDir := getCacheDir()
-	path := getCachePath()
-
-	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
-		return nil, err
-	}
-
-	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
-		return nil, err
-	}
-
-	var repos []Repository
-	if err := json.Unmarshal(data, &repos); err != nil {
-		return nil, err
-	}
-
-	for i := range repos {
-		repos[i].ComputeSearchText()
-	}
-
-	return repos, nil
+	return cacheStore().LoadRepos()
 }
 
 func stripAnsi(s string) string {
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hookEvent names a point in a repo's lifecycle where hooks run
+type hookEvent string
+
+const (
+	hookPostClone hookEvent = "post_clone"
+	hookPreOpen   hookEvent = "pre_open"
+	hookPostOpen  hookEvent = "post_open"
+)
+
+// defaultHookTimeout applies to each hook command when hooks.timeout is not set
+const defaultHookTimeout = time.Minute
+
+var ErrHookFailed = errors.New("hook failed")
+
+// hooksDoneMsg is sent when the post_clone hooks of an in-UI clone finished
+type hooksDoneMsg struct{ err error }
+
+// commands returns the hook commands configured for event
+func (h Hooks) commands(event hookEvent) []string {
+	switch event {
+	case hookPostClone:
+		return h.PostClone
+	case hookPreOpen:
+		return h.PreOpen
+	case hookPostOpen:
+		return h.PostOpen
+	}
+	return nil
+}
+
+func (h Hooks) timeout() time.Duration {
+	if h.Timeout > 0 {
+		return h.Timeout
+	}
+	return defaultHookTimeout
+}
+
+func getHooksLogPath() string {
+	return filepath.Join(getCacheDir(), "hooks.log")
+}
+
+// shellCommand runs command through the platform shell, so hooks can use
+// pipes, && and the like
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// hookEnv returns the environment hooks run with: the current environment
+// plus the repo being cloned or opened
+func hookEnv(event hookEvent, repo Repository, path string) []string {
+	return append(os.Environ(),
+		"FUZZYREPO=1",
+		"FUZZYREPO_HOOK="+string(event),
+		"FUZZYREPO_PATH="+path,
+		"FUZZYREPO_FULL_NAME="+repo.FullName,
+		"FUZZYREPO_OWNER="+repo.Owner,
+		"FUZZYREPO_NAME="+repo.Name,
+		"FUZZYREPO_AFFILIATION="+repo.Affiliation,
+		"FUZZYREPO_SSH_URL="+repo.SSHURL,
+	)
+}
+
+// runHooks runs the event's hooks for repo inside path, one after another.
+// Output goes to the hooks log. A failing or timed out command doesn't stop
+// the others; all failures are returned together. Cancelling ctx skips the
+// remaining commands.
+func runHooks(ctx context.Context, event hookEvent, repo Repository, path string, config Config) error {
+	hooks := config.GetHooks(repo.FullName)
+
+	var errs []error
+	for _, command := range hooks.commands(event) {
+		if ctx.Err() != nil {
+			errs = append(errs, fmt.Errorf("%s hooks skipped", event))
+			break
+		}
+		if err := runHook(ctx, event, command, repo, path, hooks.timeout()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func runHook(ctx context.Context, event hookEvent, command string, repo Repository, path string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := shellCommand(ctx, command)
+	cmd.Dir = path
+	cmd.Env = hookEnv(event, repo, path)
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	// Don't wait forever on background processes that keep the output open
+	cmd.WaitDelay = time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	status := "ok"
+	if err != nil {
+		status = err.Error()
+	}
+	entry := fmt.Sprintf("%s %s %s %q: %s (%s)\n",
+		start.Format(time.RFC3339), event, repo.FullName, command, status, time.Since(start).Round(time.Millisecond))
+	if out.Len() > 0 {
+		entry += indentLines(out.String(), "  ")
+	}
+	_ = appendLog(getHooksLogPath(), entry)
+
+	if err != nil {
+		if tail := lastLine(out.String()); tail != "" {
+			return fmt.Errorf("%w: %s %q: %v: %s", ErrHookFailed, event, command, err, tail)
+		}
+		return fmt.Errorf("%w: %s %q: %v", ErrHookFailed, event, command, err)
+	}
+	return nil
+}
+
+// runHooksCmd runs hooks in the background for the UI
+func runHooksCmd(ctx context.Context, event hookEvent, repo Repository, path string, config Config) tea.Cmd {
+	return func() tea.Msg {
+		return hooksDoneMsg{err: runHooks(ctx, event, repo, path, config)}
+	}
+}
+
+// reportHookError prints hook failures as a warning; hooks never block opening
+func reportHookError(err error) {
+	if err == nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %v\n(hook output is in %s)\n", err, abbreviateHome(getHooksLogPath()))
+}
+
+// indentLines prefixes every line of s, ending it with a newline
+func indentLines(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	return prefix + strings.Join(lines, "\n"+prefix) + "\n"
+}
+
+// lastLine returns the last non-empty line of s
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
@@ -0,0 +1,116 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+var (
+	ErrCloneFailed   = errors.New("git clone failed")
+	ErrAlreadyExists = errors.New("local path already exists")
+)
+
+// Clone clones repo to its clone path and returns that path. git output is
+// written to out (discarded if nil). Returns ErrAlreadyExists (with the path)
+// if the repo is already cloned or the destination is taken.
+func Clone(ctx context.Context, repo Repository, config Config, out io.Writer) (string, error) {
+	destPath, args, err := PrepareClone(repo, config)
+	if err != nil {
+		return destPath, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrCloneFailed, err)
+	}
+
+	return destPath, nil
+}
+
+// EnsureLocal returns the local path of repo, cloning it first if it has no
+// local clone yet. See Clone for out and the errors.
+func EnsureLocal(ctx context.Context, repo Repository, config Config, out io.Writer) (string, error) {
+	if repo.ExistsLocal && repo.LocalPath != "" {
+		return repo.LocalPath, nil
+	}
+	return Clone(ctx, repo, config, out)
+}
+
+// PrepareClone resolves the clone destination and git arguments for repo and
+// creates the parent directory, for callers running git clone themselves.
+// Returns ErrAlreadyExists (with the path) if the repo is already cloned or
+// the destination is taken.
+func PrepareClone(repo Repository, config Config) (destPath string, args []string, err error) {
+	if repo.ExistsLocal && repo.LocalPath != "" {
+		return repo.LocalPath, nil, ErrAlreadyExists
+	}
+
+	destPath = config.GetClonePath(repo.FullName, repo.Name)
+	destDir := filepath.Dir(destPath)
+
+	if _, err := os.Stat(destPath); err == nil {
+		return destPath, nil, ErrAlreadyExists
+	}
+
+	if err := os.MkdirAll(destDir, 0o755); err != nil {
+		return "", nil, fmt.Errorf("create clone directory: %w", err)
+	}
+
+	opts := config.GetCloneOptions(repo.FullName)
+	return destPath, cloneArgs(opts, CloneURL(repo, opts.Protocol), destPath), nil
+}
+
+// CloneURL returns the URL to clone repo with. For GitHub repos the protocol
+// ("ssh" or "https") picks the URL form; empty keeps the stored URL.
+func CloneURL(repo Repository, protocol string) string {
+	owner, name := repo.Owner, repo.Name
+	if o, n, ok := ParseGitHubURL(repo.SSHURL); ok {
+		owner, name = o, n
+	} else if repo.SSHURL != "" {
+		// Not a GitHub URL, clone it as-is
+		return repo.SSHURL
+	}
+
+	switch {
+	case protocol == "https":
+		return fmt.Sprintf("https://github.com/%s/%s.git", owner, name)
+	case protocol == "" && repo.SSHURL != "":
+		return repo.SSHURL
+	default:
+		return fmt.Sprintf("git@github.com:%s/%s.git", owner, name)
+	}
+}
+
+// cloneArgs builds the git arguments for cloning url into dest
+func cloneArgs(opts CloneOptions, url, dest string) []string {
+	args := []string{"clone"}
+	if opts.Depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(opts.Depth))
+	}
+	if opts.Filter != "" {
+		args = append(args, "--filter="+opts.Filter)
+	}
+	if opts.SingleBranch != nil && *opts.SingleBranch {
+		args = append(args, "--single-branch")
+	}
+	if opts.Branch != "" {
+		args = append(args, "--branch", opts.Branch)
+	}
+	if opts.RecurseSubmodules != nil && *opts.RecurseSubmodules {
+		args = append(args, "--recurse-submodules")
+	}
+	if opts.Origin != "" {
+		args = append(args, "--origin", opts.Origin)
+	}
+	args = append(args, opts.ExtraArgs...)
+	return append(args, "--", url, dest)
+}
@@ -0,0 +1,91 @@
+package index
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defaultCloneHost is the {host} placeholder value; GitHub is the only provider
+const defaultCloneHost = "github.com"
+
+var (
+	// placeholderPattern matches {name} placeholders. Capture group
+	// references are expanded or stripped before it is applied.
+	placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+	// captureRefPattern matches $1, ${1} and ${name} capture group references
+	captureRefPattern = regexp.MustCompile(`\$(\d+)|\$\{(\w+)\}`)
+)
+
+// clonePathPlaceholders lists the supported {placeholder} names
+var clonePathPlaceholders = []string{"host", "owner", "repo", "full_name"}
+
+// hasPathVariables reports whether a template uses placeholders or capture groups
+func hasPathVariables(template string) bool {
+	return captureRefPattern.MatchString(template) || placeholderPattern.MatchString(template)
+}
+
+// ExpandClonePath turns a clone path template into the destination path.
+//
+// Templates may use {host}, {owner}, {repo} and {full_name}, plus capture
+// groups of the matching rule pattern as $1, ${1} or ${name}. A template
+// without any of these is treated as a parent directory and the repo name
+// is appended, e.g. "/src" -> "/src/<repo>" and
+// "/src/{host}/{owner}/{repo}" -> "/src/github.com/<owner>/<repo>".
+func ExpandClonePath(template, fullName, repoName string, re *regexp.Regexp, match []int) string {
+	if !hasPathVariables(template) {
+		return filepath.Join(template, repoName)
+	}
+
+	path := template
+	if re != nil && match != nil {
+		path = string(re.ExpandString(nil, template, fullName, match))
+	}
+
+	owner, _, _ := strings.Cut(fullName, "/")
+	values := map[string]string{
+		"host":      defaultCloneHost,
+		"owner":     owner,
+		"repo":      repoName,
+		"full_name": fullName,
+	}
+	path = placeholderPattern.ReplaceAllStringFunc(path, func(s string) string {
+		return values[s[1:len(s)-1]]
+	})
+
+	return filepath.Clean(path)
+}
+
+// ValidateClonePathTemplate checks that a template only uses known
+// placeholders and, when re is given, capture groups that exist in it
+func ValidateClonePathTemplate(template string, re *regexp.Regexp) error {
+	withoutRefs := captureRefPattern.ReplaceAllString(template, "")
+	for _, m := range placeholderPattern.FindAllStringSubmatch(withoutRefs, -1) {
+		if !slices.Contains(clonePathPlaceholders, m[1]) {
+			return fmt.Errorf("unknown placeholder {%s} (use {%s})", m[1], strings.Join(clonePathPlaceholders, "}, {"))
+		}
+	}
+
+	refs := captureRefPattern.FindAllStringSubmatch(template, -1)
+	if len(refs) > 0 && re == nil {
+		return fmt.Errorf("capture group %s can only be used in clone rule paths", refs[0][0])
+	}
+	for _, ref := range refs {
+		group := ref[1] + ref[2]
+		if n, err := strconv.Atoi(group); err == nil {
+			if n > re.NumSubexp() {
+				return fmt.Errorf("capture group %s does not exist in pattern %q", ref[0], re.String())
+			}
+			continue
+		}
+		if re.SubexpIndex(group) < 0 {
+			return fmt.Errorf("capture group %s does not exist in pattern %q", ref[0], re.String())
+		}
+	}
+
+	return nil
+}
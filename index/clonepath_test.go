@@ -0,0 +1,57 @@
+package index
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestExpandClonePath(t *testing.T) {
+	re := regexp.MustCompile(`^(?P<org>acme)-(\w+)/(.+)$`)
+	fullName := "acme-platform/api"
+	match := re.FindStringSubmatchIndex(fullName)
+
+	tests := []struct {
+		template string
+		re       *regexp.Regexp
+		want     string
+	}{
+		{"/src", nil, "/src/api"},
+		{"/src/", nil, "/src/api"},
+		{"/src/{owner}/{repo}", nil, "/src/acme-platform/api"},
+		{"/src/{host}/{full_name}", nil, "/src/github.com/acme-platform/api"},
+		{"/work/$2/{repo}", re, "/work/platform/api"},
+		{"/work/${org}/${2}-$3", re, "/work/acme/platform-api"},
+		{"/work/{owner}/../{repo}", nil, "/work/api"},
+	}
+	for _, tt := range tests {
+		var m []int
+		if tt.re != nil {
+			m = match
+		}
+		if got := ExpandClonePath(tt.template, fullName, "api", tt.re, m); got != tt.want {
+			t.Errorf("ExpandClonePath(%q) = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestValidateClonePathTemplate(t *testing.T) {
+	re := regexp.MustCompile(`^(?P<org>\w+)/(.+)$`)
+	tests := []struct {
+		template string
+		re       *regexp.Regexp
+		ok       bool
+	}{
+		{"/src/{owner}/{repo}", nil, true},
+		{"/src/{project}", nil, false},
+		{"/src/$1", nil, false},
+		{"/src/$1/${org}/$2", re, true},
+		{"/src/$3", re, false},
+		{"/src/${team}", re, false},
+	}
+	for _, tt := range tests {
+		err := ValidateClonePathTemplate(tt.template, tt.re)
+		if (err == nil) != tt.ok {
+			t.Errorf("ValidateClonePathTemplate(%q) = %v, want ok %v", tt.template, err, tt.ok)
+		}
+	}
+}
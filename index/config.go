@@ -0,0 +1,220 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Config says where repos are found and where, and how, they are cloned.
+// It has the layout of the matching keys of the fuzzyrepo config file.
+type Config struct {
+	RepoRoots     []string     `yaml:"repo_roots"`            // Directories scanned for local clones
+	CloneRoot     string       `yaml:"clone_root,omitempty"`  // Clone path template, default the first repo root
+	UseCloneRules bool         `yaml:"use_clone_rules"`       // Enable regex-based clone path rules
+	CloneRules    []CloneRule  `yaml:"clone_rules,omitempty"` // Ordered rules for clone path, first match wins
+	Clone         CloneOptions `yaml:"clone,omitempty"`       // git clone settings, overridable per clone rule
+}
+
+// CloneRule defines a regex pattern to match repo full_name and a target directory
+type CloneRule struct {
+	Pattern string        `yaml:"pattern"`         // Regex pattern to match against full_name (owner/repo)
+	Path    string        `yaml:"path"`            // Target directory template, see ExpandClonePath
+	Clone   *CloneOptions `yaml:"clone,omitempty"` // Overrides the global clone options for matching repos
+
+	re *regexp.Regexp // Compiled Pattern, set by Compile
+}
+
+// Compile compiles the pattern once, so matching doesn't recompile it
+func (r *CloneRule) Compile() error {
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regex pattern %q: %v", r.Pattern, err)
+	}
+	r.re = re
+	return nil
+}
+
+// Regexp returns the compiled pattern, compiling it on demand if the rule
+// was not precompiled. Returns nil for an invalid pattern.
+func (r CloneRule) Regexp() *regexp.Regexp {
+	if r.re != nil {
+		return r.re
+	}
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return nil
+	}
+	return re
+}
+
+// Validate checks the rule pattern and its path template
+func (r CloneRule) Validate() error {
+	if r.Pattern == "" {
+		return errors.New("pattern cannot be empty")
+	}
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regex pattern %q: %v", r.Pattern, err)
+	}
+	if r.Path == "" {
+		return errors.New("path cannot be empty")
+	}
+	if !filepath.IsAbs(r.Path) {
+		return fmt.Errorf("path must be absolute (got %q)", r.Path)
+	}
+	if err := ValidateClonePathTemplate(r.Path, re); err != nil {
+		return fmt.Errorf("path: %w", err)
+	}
+	return nil
+}
+
+// CloneOptions controls how git clone is invoked.
+// Zero values mean "not set" so rule options can be layered over the global ones.
+type CloneOptions struct {
+	Protocol          string   `yaml:"protocol,omitempty"`           // "ssh" (default) or "https"
+	Depth             int      `yaml:"depth,omitempty"`              // --depth, 0 = full history
+	Filter            string   `yaml:"filter,omitempty"`             // --filter, e.g. "blob:none" for a partial clone
+	SingleBranch      *bool    `yaml:"single_branch,omitempty"`      // --single-branch
+	Branch            string   `yaml:"branch,omitempty"`             // --branch
+	RecurseSubmodules *bool    `yaml:"recurse_submodules,omitempty"` // --recurse-submodules
+	Origin            string   `yaml:"origin,omitempty"`             // --origin, remote name instead of "origin"
+	ExtraArgs         []string `yaml:"extra_args,omitempty"`         // Additional git clone flags, appended as-is
+}
+
+// Merge returns o with every option set in override replacing the original
+func (o CloneOptions) Merge(override *CloneOptions) CloneOptions {
+	if override == nil {
+		return o
+	}
+	if override.Protocol != "" {
+		o.Protocol = override.Protocol
+	}
+	if override.Depth != 0 {
+		o.Depth = override.Depth
+	}
+	if override.Filter != "" {
+		o.Filter = override.Filter
+	}
+	if override.SingleBranch != nil {
+		o.SingleBranch = override.SingleBranch
+	}
+	if override.Branch != "" {
+		o.Branch = override.Branch
+	}
+	if override.RecurseSubmodules != nil {
+		o.RecurseSubmodules = override.RecurseSubmodules
+	}
+	if override.Origin != "" {
+		o.Origin = override.Origin
+	}
+	if len(override.ExtraArgs) > 0 {
+		o.ExtraArgs = append(append([]string{}, o.ExtraArgs...), override.ExtraArgs...)
+	}
+	return o
+}
+
+// Validate checks the clone options; field is the config key used in errors
+func (o CloneOptions) Validate(field string) error {
+	switch o.Protocol {
+	case "", "ssh", "https":
+	default:
+		return fmt.Errorf("%s.protocol must be ssh or https (got %q)", field, o.Protocol)
+	}
+	if o.Depth < 0 {
+		return fmt.Errorf("%s.depth cannot be negative", field)
+	}
+	for _, arg := range o.ExtraArgs {
+		if !strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("%s.extra_args must be flags starting with '-' (got %q), use --flag=value for values", field, arg)
+		}
+	}
+	return nil
+}
+
+// GetCloneRoot returns the clone_root template, defaulting to the first repo
+// root and then ~/repos
+func (c Config) GetCloneRoot() string {
+	if c.CloneRoot != "" {
+		return c.CloneRoot
+	}
+	if len(c.RepoRoots) > 0 {
+		return c.RepoRoots[0]
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, "repos")
+}
+
+// MatchCloneRule returns the index of the first clone rule matching fullName
+// along with the submatch indices for expanding capture groups.
+// Returns false if UseCloneRules is disabled or no rule matches.
+func (c Config) MatchCloneRule(fullName string) (int, []int, bool) {
+	if !c.UseCloneRules {
+		return -1, nil, false
+	}
+	for i, rule := range c.CloneRules {
+		re := rule.Regexp()
+		if re == nil {
+			// Invalid regex, skip this rule
+			continue
+		}
+		if match := re.FindStringSubmatchIndex(fullName); match != nil {
+			return i, match, true
+		}
+	}
+	return -1, nil, false
+}
+
+// GetClonePath returns the full destination path for cloning a repo.
+// If UseCloneRules is enabled, the first matching rule's path template is used,
+// otherwise the clone_root template. See ExpandClonePath for the placeholders.
+func (c Config) GetClonePath(fullName, repoName string) string {
+	if i, match, ok := c.MatchCloneRule(fullName); ok {
+		rule := c.CloneRules[i]
+		return ExpandClonePath(rule.Path, fullName, repoName, rule.Regexp(), match)
+	}
+	// No rules matched or UseCloneRules disabled, use default clone root
+	return ExpandClonePath(c.GetCloneRoot(), fullName, repoName, nil, nil)
+}
+
+// GetCloneOptions returns the clone options for a repo: the global options
+// with those of the matching clone rule layered on top
+func (c Config) GetCloneOptions(fullName string) CloneOptions {
+	if i, _, ok := c.MatchCloneRule(fullName); ok {
+		return c.Clone.Merge(c.CloneRules[i].Clone)
+	}
+	return c.Clone
+}
+
+// Validate checks the repo roots, the clone path templates, the clone rules
+// and the clone options
+func (c Config) Validate() error {
+	for _, root := range c.RepoRoots {
+		if !filepath.IsAbs(root) {
+			return fmt.Errorf("repo_roots must contain absolute paths (got %q)", root)
+		}
+	}
+
+	if c.CloneRoot != "" && !filepath.IsAbs(c.CloneRoot) {
+		return fmt.Errorf("clone_root must be an absolute path (got %q)", c.CloneRoot)
+	}
+	if err := ValidateClonePathTemplate(c.CloneRoot, nil); err != nil {
+		return fmt.Errorf("clone_root: %w", err)
+	}
+
+	for i, rule := range c.CloneRules {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("clone_rules[%d]: %w", i, err)
+		}
+		if rule.Clone != nil {
+			if err := rule.Clone.Validate(fmt.Sprintf("clone_rules[%d].clone", i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return c.Clone.Validate("clone")
+}
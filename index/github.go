@@ -1,4 +1,4 @@
-package main
+package index
 
 import (
 	"context"
@@ -10,36 +10,41 @@ import (
 	"golang.org/x/oauth2"
 )
 
-func getAuthToken() (string, error) {
-	cmd := exec.Command("gh", "auth", "token")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
-	return strings.TrimSpace(string(output)), nil
-}
+// GitHubProvider lists the repos the authenticated GitHub user has access to
+type GitHubProvider struct {
+	// Comma-separated affiliations to list, e.g. "owner,collaborator".
+	// Repos keep the first affiliation they were listed with.
+	Affiliation string
 
-func getGithubClient(ctx context.Context) (*github.Client, error) {
-	token, err := getAuthToken()
-	if err != nil {
-		return nil, fmt.Errorf("not logged into gh: %w", err)
-	}
+	// Token authenticates the API calls. Empty uses "gh auth token".
+	Token string
 
-	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
-	client := oauth2.NewClient(ctx, ts)
+	// Progress, if set, is called after each page of results with the
+	// number of repos listed so far over all affiliations
+	Progress func(affiliation string, page, repos int)
+}
 
-	return github.NewClient(client), nil
+func (p GitHubProvider) Name() string {
+	return "github"
 }
 
-func getRemoteRepositories(ctx context.Context, githubClient *github.Client, cfg Config) ([]Repository, error) {
-	var allRepos []Repository
+func (p GitHubProvider) List(ctx context.Context) ([]Repository, error) {
+	githubClient, err := p.client(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	// Parse affiliations from config
-	affiliations := parseAffiliations(cfg.GitHub.Affiliation)
+	var allRepos []Repository
 
 	// Fetch repos for each affiliation separately to track the affiliation type
-	for _, affiliation := range affiliations {
-		repos, err := fetchReposWithAffiliation(ctx, githubClient, affiliation)
+	for _, affiliation := range parseAffiliations(p.Affiliation) {
+		listed := len(allRepos)
+		progress := func(page, repos int) {
+			if p.Progress != nil {
+				p.Progress(affiliation, page, listed+repos)
+			}
+		}
+		repos, err := fetchReposWithAffiliation(ctx, githubClient, affiliation, progress)
 		if err != nil {
 			return nil, err
 		}
@@ -50,6 +55,30 @@ func getRemoteRepositories(ctx context.Context, githubClient *github.Client, cfg
 	return deduplicateRepos(allRepos), nil
 }
 
+func getAuthToken() (string, error) {
+	cmd := exec.Command("gh", "auth", "token")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func (p GitHubProvider) client(ctx context.Context) (*github.Client, error) {
+	token := p.Token
+	if token == "" {
+		var err error
+		if token, err = getAuthToken(); err != nil {
+			return nil, fmt.Errorf("not logged into gh: %w", err)
+		}
+	}
+
+	ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+	client := oauth2.NewClient(ctx, ts)
+
+	return github.NewClient(client), nil
+}
+
 // parseAffiliations splits the affiliation string into individual types
 func parseAffiliations(affiliation string) []string {
 	var result []string
@@ -62,8 +91,9 @@ func parseAffiliations(affiliation string) []string {
 	return result
 }
 
-// fetchReposWithAffiliation fetches repos for a single affiliation type
-func fetchReposWithAffiliation(ctx context.Context, githubClient *github.Client, affiliation string) ([]Repository, error) {
+// fetchReposWithAffiliation fetches repos for a single affiliation type,
+// calling progress after each page
+func fetchReposWithAffiliation(ctx context.Context, githubClient *github.Client, affiliation string, progress func(page, repos int)) ([]Repository, error) {
 	opts := &github.RepositoryListOptions{
 		Visibility:  "all",
 		Affiliation: affiliation,
@@ -72,7 +102,7 @@ func fetchReposWithAffiliation(ctx context.Context, githubClient *github.Client,
 
 	var repos []Repository
 
-	for {
+	for page := 1; ; page++ {
 		remoteRepos, resp, err := githubClient.Repositories.List(ctx, "", opts)
 		if err != nil {
 			return nil, err
@@ -90,10 +120,15 @@ func fetchReposWithAffiliation(ctx context.Context, githubClient *github.Client,
 				LocalPath:   "",
 				ExistsLocal: false,
 				Affiliation: affiliation,
+				Language:    repo.GetLanguage(),
+				Stars:       repo.GetStargazersCount(),
+				PushedAt:    repo.GetPushedAt().Time,
+				Archived:    repo.GetArchived(),
 			}
 			r.ComputeSearchText()
 			repos = append(repos, r)
 		}
+		progress(page, len(repos))
 
 		if resp.NextPage == 0 {
 			break
@@ -0,0 +1,73 @@
+package index
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// ErrLocked is returned by TryLockFile and TryRLockFile when another
+// process holds the lock
+var ErrLocked = errors.New("locked by another process")
+
+// Lock is an advisory lock (flock) on a lock file. The OS releases it when
+// the process exits, so a crashed process can't leave a stale lock behind.
+//
+// Lock files sit next to the files they guard, e.g. usage.json.lock: the
+// guarded files are replaced by rename, which would drop a lock held on them.
+type Lock struct {
+	f *os.File
+}
+
+// LockFile waits until it holds the lock on the lock file at path, creating
+// the file and its directory if needed
+func LockFile(path string) (*Lock, error) {
+	return lockFile(path, false, true)
+}
+
+// TryLockFile takes the lock on the lock file at path without waiting.
+// Returns ErrLocked if another process, or another Lock of this process,
+// holds it.
+func TryLockFile(path string) (*Lock, error) {
+	return lockFile(path, false, false)
+}
+
+// TryRLockFile takes a shared lock on the lock file at path without
+// waiting. Any number of shared locks can be held at once; returns
+// ErrLocked if the lock is held exclusively. Taking and dropping it checks
+// whether the lock is held without keeping an exclusive holder out for
+// long.
+func TryRLockFile(path string) (*Lock, error) {
+	return lockFile(path, true, false)
+}
+
+func lockFile(path string, shared, wait bool) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := flock(f, shared, wait); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &Lock{f: f}, nil
+}
+
+// Unlock releases the lock. The lock file is kept, removing it would let
+// a process waiting on the old file and one creating a new file both hold
+// "the" lock.
+func (l *Lock) Unlock() error {
+	if err := funlock(l.f); err != nil {
+		l.f.Close()
+		return err
+	}
+	return l.f.Close()
+}
+
+// File returns the open lock file, e.g. to note the holder in it
+func (l *Lock) File() *os.File {
+	return l.f
+}
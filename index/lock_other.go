@@ -0,0 +1,15 @@
+//go:build !unix
+
+package index
+
+import "os"
+
+// flock is a no-op where flock is not available: locks always succeed, so
+// concurrent processes are not kept apart
+func flock(f *os.File, shared, wait bool) error {
+	return nil
+}
+
+func funlock(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,38 @@
+//go:build unix
+
+package index
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// flock takes an exclusive (or shared) lock on f, waiting for it if wait
+// is set
+func flock(f *os.File, shared, wait bool) error {
+	how := syscall.LOCK_EX
+	if shared {
+		how = syscall.LOCK_SH
+	}
+	if !wait {
+		how |= syscall.LOCK_NB
+	}
+	for {
+		err := syscall.Flock(int(f.Fd()), how)
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, syscall.EINTR):
+			// Interrupted by a signal while waiting, try again
+		case errors.Is(err, syscall.EWOULDBLOCK):
+			return ErrLocked
+		default:
+			return &os.PathError{Op: "flock", Path: f.Name(), Err: err}
+		}
+	}
+}
+
+func funlock(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
@@ -0,0 +1,159 @@
+package index
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sahilm/fuzzy"
+)
+
+// SortMode controls how results are ordered
+type SortMode string
+
+const (
+	SortFrecency SortMode = "frecency" // Fuzzy score plus usage boost
+	SortName     SortMode = "name"     // Alphabetical by full name
+	SortPushed   SortMode = "pushed"   // Most recently pushed on GitHub
+	SortUsed     SortMode = "used"     // Most recently opened from fuzzyrepo
+	SortOwner    SortMode = "owner"    // Grouped by owner, frecency within a group
+)
+
+// SortModes lists the modes in the order they are cycled through
+var SortModes = []SortMode{SortFrecency, SortName, SortPushed, SortUsed, SortOwner}
+
+// ParseSortMode returns the mode for s, falling back to frecency for unknown values
+func ParseSortMode(s string) SortMode {
+	for _, mode := range SortModes {
+		if string(mode) == s {
+			return mode
+		}
+	}
+	return SortFrecency
+}
+
+// Next returns the mode after m, wrapping around
+func (m SortMode) Next() SortMode {
+	for i, mode := range SortModes {
+		if mode == m {
+			return SortModes[(i+1)%len(SortModes)]
+		}
+	}
+	return SortFrecency
+}
+
+// Rank filters repos by the fuzzy query and orders them by mode, best first.
+// In frecency mode the fuzzy score is combined with the usage boost, without
+// a query the repos are ordered by usage alone.
+func Rank(repos []Repository, query string, usage UsageData, mode SortMode) []Repository {
+	q := strings.TrimSpace(query)
+
+	if mode == SortFrecency {
+		if q == "" {
+			return SortByUsage(repos, usage)
+		}
+		return fuzzyRank(repos, q, usage)
+	}
+
+	matched := repos
+	if q != "" {
+		matched = fuzzyMatch(repos, q)
+	}
+
+	ranked := make([]Repository, len(matched))
+	copy(ranked, matched)
+	sortRepos(ranked, usage, mode)
+	return ranked
+}
+
+// fuzzyMatch returns the repos matching the query, in their original order
+func fuzzyMatch(repos []Repository, query string) []Repository {
+	haystack := make([]string, 0, len(repos))
+	for _, r := range repos {
+		haystack = append(haystack, r.SearchText)
+	}
+
+	matches := fuzzy.Find(query, haystack)
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Index < matches[j].Index
+	})
+
+	result := make([]Repository, 0, len(matches))
+	for _, mt := range matches {
+		result = append(result, repos[mt.Index])
+	}
+	return result
+}
+
+// fuzzyRank orders matches by fuzzy score combined with frecency, best first
+func fuzzyRank(repos []Repository, query string, usage UsageData) []Repository {
+	haystack := make([]string, 0, len(repos))
+	for _, r := range repos {
+		haystack = append(haystack, r.SearchText)
+	}
+
+	matches := fuzzy.Find(query, haystack)
+
+	type scoredRepo struct {
+		repo       Repository
+		fuzzyScore int
+		usageBoost float64
+		combined   float64
+	}
+
+	scored := make([]scoredRepo, 0, len(matches))
+	for _, mt := range matches {
+		repo := repos[mt.Index]
+		usageBoost := GetUsageBoost(usage, repo)
+		combined := float64(mt.Score) + usageBoost*50
+		scored = append(scored, scoredRepo{
+			repo:       repo,
+			fuzzyScore: mt.Score,
+			usageBoost: usageBoost,
+			combined:   combined,
+		})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].combined < scored[j].combined
+	})
+
+	// scored is ascending, so the best match is last
+	result := make([]Repository, 0, len(scored))
+	for i := len(scored) - 1; i >= 0; i-- {
+		result = append(result, scored[i].repo)
+	}
+	return result
+}
+
+// sortRepos sorts repos best first according to a non-frecency mode
+func sortRepos(repos []Repository, usage UsageData, mode SortMode) {
+	byName := func(a, b Repository) bool {
+		return strings.ToLower(a.FullName) < strings.ToLower(b.FullName)
+	}
+
+	sort.SliceStable(repos, func(i, j int) bool {
+		a, b := repos[i], repos[j]
+		switch mode {
+		case SortPushed:
+			if !a.PushedAt.Equal(b.PushedAt) {
+				return a.PushedAt.After(b.PushedAt)
+			}
+		case SortUsed:
+			ua := usage[strings.ToLower(a.FullName)].LastUsedAt
+			ub := usage[strings.ToLower(b.FullName)].LastUsedAt
+			if !ua.Equal(ub) {
+				return ua.After(ub)
+			}
+		case SortOwner:
+			oa, ob := strings.ToLower(a.Owner), strings.ToLower(b.Owner)
+			if oa != ob {
+				return oa < ob
+			}
+			ba, bb := GetUsageBoost(usage, a), GetUsageBoost(usage, b)
+			if ba != bb {
+				return ba > bb
+			}
+		}
+		return byName(a, b)
+	})
+}
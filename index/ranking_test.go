@@ -0,0 +1,78 @@
+package index
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func testRepos() []Repository {
+	now := time.Now()
+	repos := []Repository{
+		{Owner: "acme", Name: "web", FullName: "acme/web", PushedAt: now.Add(-time.Hour)},
+		{Owner: "zeta", Name: "api", FullName: "zeta/api", PushedAt: now},
+		{Owner: "acme", Name: "api", FullName: "acme/api", PushedAt: now.Add(-48 * time.Hour)},
+	}
+	for i := range repos {
+		repos[i].ComputeSearchText()
+	}
+	return repos
+}
+
+func fullNames(repos []Repository) []string {
+	names := make([]string, len(repos))
+	for i, r := range repos {
+		names[i] = r.FullName
+	}
+	return names
+}
+
+func TestRank(t *testing.T) {
+	now := time.Now()
+	usage := UsageData{
+		"zeta/api": {Count: 5, LastUsedAt: now.Add(-time.Hour)},
+		"acme/web": {Count: 1, LastUsedAt: now},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		mode  SortMode
+		want  []string
+	}{
+		{"by usage without a query", "", SortFrecency, []string{"zeta/api", "acme/web", "acme/api"}},
+		{"usage breaks fuzzy ties", "api", SortFrecency, []string{"zeta/api", "acme/api"}},
+		{"query filters", "web", SortFrecency, []string{"acme/web"}},
+		{"by name", "", SortName, []string{"acme/api", "acme/web", "zeta/api"}},
+		{"by name with a query", "api", SortName, []string{"acme/api", "zeta/api"}},
+		{"by push", "", SortPushed, []string{"zeta/api", "acme/web", "acme/api"}},
+		{"by last use", "", SortUsed, []string{"acme/web", "zeta/api", "acme/api"}},
+		{"by owner, then usage", "", SortOwner, []string{"acme/web", "acme/api", "zeta/api"}},
+		{"no match", "nothing", SortName, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := testRepos()
+			got := fullNames(Rank(repos, tt.query, usage, tt.mode))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Rank(%q, %s) = %v, want %v", tt.query, tt.mode, got, tt.want)
+			}
+			if !slices.Equal(fullNames(repos), fullNames(testRepos())) {
+				t.Errorf("Rank reordered its input: %v", fullNames(repos))
+			}
+		})
+	}
+}
+
+func TestSortModeNext(t *testing.T) {
+	mode := SortFrecency
+	for range SortModes {
+		mode = mode.Next()
+	}
+	if mode != SortFrecency {
+		t.Errorf("cycling through all modes ends at %s", mode)
+	}
+	if got := ParseSortMode("bogus"); got != SortFrecency {
+		t.Errorf("ParseSortMode(bogus) = %s, want %s", got, SortFrecency)
+	}
+}
@@ -0,0 +1,181 @@
+// Package index is the repository index behind fuzzyrepo: the repos found on
+// GitHub and in local directories, the cache files they are kept in,
+// frecency ranking, clone path rules and cloning.
+//
+// The fuzzyrepo CLI is built on this package, so other tools using it read
+// and update the same index and usage history.
+package index
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Affiliation of repos found in local directories but not on GitHub
+const AffiliationLocal = "local"
+
+type Repository struct {
+	Owner       string `json:"owner"`
+	Name        string `json:"name"`
+	FullName    string `json:"full_name"`
+	SSHURL      string `json:"ssh_url"`
+	LocalPath   string `json:"local_path"`
+	ExistsLocal bool   `json:"exists_local"`
+	Affiliation string `json:"affiliation"` // "owner", "collaborator", "organization_member", "local"
+
+	// Remote metadata, only set for repos fetched from GitHub
+	Language string    `json:"language,omitempty"`
+	Stars    int       `json:"stars,omitempty"`
+	PushedAt time.Time `json:"pushed_at,omitzero"`
+	Archived bool      `json:"archived,omitempty"`
+
+	SearchText string `json:"-"`
+}
+
+// ComputeSearchText sets the text fuzzy queries are matched against. Repos
+// loaded or listed by this package already have it.
+func (r *Repository) ComputeSearchText() {
+	r.SearchText = strings.ToLower(r.Owner + " " + r.Name + " " + r.FullName)
+}
+
+func extractOriginURL(gitConfigPath string) (string, error) {
+	file, err := os.Open(gitConfigPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	inOriginSection := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if strings.HasPrefix(line, "[remote \"origin\"]") {
+			inOriginSection = true
+			continue
+		}
+
+		if inOriginSection {
+			if strings.HasPrefix(line, "[") {
+				break
+			}
+			if strings.HasPrefix(line, "url") {
+				parts := strings.SplitN(line, "=", 2)
+				if len(parts) == 2 {
+					return strings.TrimSpace(parts[1]), nil
+				}
+			}
+		}
+	}
+
+	return "", nil
+}
+
+var (
+	sshURLPattern   = regexp.MustCompile(`git@github\.com:([^/]+)/(.+?)(?:\.git)?$`)
+	httpsURLPattern = regexp.MustCompile(`https://github\.com/([^/]+)/(.+?)(?:\.git)?$`)
+)
+
+// ParseGitHubURL returns the owner and name of a GitHub SSH or HTTPS URL
+func ParseGitHubURL(url string) (owner, name string, ok bool) {
+	if matches := sshURLPattern.FindStringSubmatch(url); matches != nil {
+		return matches[1], strings.TrimSuffix(matches[2], ".git"), true
+	}
+	if matches := httpsURLPattern.FindStringSubmatch(url); matches != nil {
+		return matches[1], strings.TrimSuffix(matches[2], ".git"), true
+	}
+	return "", "", false
+}
+
+// ScanLocal walks the roots for git repositories. Repos with a GitHub origin
+// are named after it, others get the owner "local".
+func ScanLocal(roots []string) []Repository {
+	var repos []Repository
+
+	for _, root := range roots {
+		if root == "" {
+			continue
+		}
+
+		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+
+			if d.IsDir() && d.Name() == ".git" {
+				repoPath := filepath.Dir(path)
+				repo := buildRepoFromLocalPath(repoPath)
+				repos = append(repos, repo)
+				return fs.SkipDir
+			}
+
+			if d.IsDir() && (d.Name() == "node_modules" || d.Name() == "vendor" || d.Name() == ".cache") {
+				return fs.SkipDir
+			}
+
+			return nil
+		})
+	}
+
+	return repos
+}
+
+func buildRepoFromLocalPath(repoPath string) Repository {
+	gitConfigPath := filepath.Join(repoPath, ".git", "config")
+	originURL, _ := extractOriginURL(gitConfigPath)
+
+	owner, name, ok := ParseGitHubURL(originURL)
+	if !ok {
+		name = filepath.Base(repoPath)
+		owner = "local"
+	}
+
+	repo := Repository{
+		Owner:       owner,
+		Name:        name,
+		FullName:    owner + "/" + name,
+		SSHURL:      originURL,
+		LocalPath:   repoPath,
+		ExistsLocal: true,
+		Affiliation: AffiliationLocal,
+	}
+	repo.ComputeSearchText()
+
+	return repo
+}
+
+// MergeRepos combines local clones with remote repos, matching them by full
+// name regardless of case. A remote repo that is cloned keeps its metadata
+// and gets the local path; local repos not found remotely are kept as-is.
+func MergeRepos(local, remote []Repository) []Repository {
+	repoMap := make(map[string]Repository)
+
+	for _, r := range remote {
+		key := strings.ToLower(r.FullName)
+		repoMap[key] = r
+	}
+
+	for _, r := range local {
+		key := strings.ToLower(r.FullName)
+		if existing, ok := repoMap[key]; ok {
+			existing.LocalPath = r.LocalPath
+			existing.ExistsLocal = true
+			existing.ComputeSearchText()
+			repoMap[key] = existing
+		} else {
+			repoMap[key] = r
+		}
+	}
+
+	result := make([]Repository, 0, len(repoMap))
+	for _, r := range repoMap {
+		result = append(result, r)
+	}
+
+	return result
+}
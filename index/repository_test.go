@@ -0,0 +1,106 @@
+package index
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func byFullName(repos []Repository) map[string]Repository {
+	m := make(map[string]Repository, len(repos))
+	for _, r := range repos {
+		m[r.FullName] = r
+	}
+	return m
+}
+
+func TestMergeRepos(t *testing.T) {
+	remote := []Repository{
+		{Owner: "acme", Name: "api", FullName: "acme/api", Affiliation: "owner", Stars: 3},
+		{Owner: "acme", Name: "web", FullName: "acme/web", Affiliation: "owner"},
+	}
+	local := []Repository{
+		{Owner: "Acme", Name: "API", FullName: "Acme/API", LocalPath: "/src/api", ExistsLocal: true, Affiliation: AffiliationLocal},
+		{Owner: "local", Name: "notes", FullName: "local/notes", LocalPath: "/src/notes", ExistsLocal: true, Affiliation: AffiliationLocal},
+	}
+
+	merged := byFullName(MergeRepos(local, remote))
+	if len(merged) != 3 {
+		t.Fatalf("merged %d repos, want 3: %v", len(merged), merged)
+	}
+
+	api := merged["acme/api"]
+	if !api.ExistsLocal || api.LocalPath != "/src/api" {
+		t.Errorf("acme/api is not matched with its clone: %+v", api)
+	}
+	if api.Affiliation != "owner" || api.Stars != 3 {
+		t.Errorf("acme/api lost its remote metadata: %+v", api)
+	}
+	if api.SearchText == "" {
+		t.Error("acme/api has no search text")
+	}
+	if web := merged["acme/web"]; web.ExistsLocal {
+		t.Errorf("acme/web is not cloned: %+v", web)
+	}
+	if notes := merged["local/notes"]; notes.Affiliation != AffiliationLocal || notes.LocalPath != "/src/notes" {
+		t.Errorf("local-only repo not kept as-is: %+v", notes)
+	}
+}
+
+type fakeProvider struct {
+	name  string
+	repos []Repository
+	err   error
+}
+
+func (p fakeProvider) Name() string {
+	return p.name
+}
+
+func (p fakeProvider) List(context.Context) ([]Repository, error) {
+	return p.repos, p.err
+}
+
+func TestSync(t *testing.T) {
+	local := fakeProvider{name: "local", repos: []Repository{
+		{FullName: "acme/api", LocalPath: "/src/api", ExistsLocal: true, Affiliation: AffiliationLocal},
+	}}
+	remote := fakeProvider{name: "github", repos: []Repository{
+		{FullName: "acme/api", Affiliation: "owner"},
+		{FullName: "acme/web", Affiliation: "owner"},
+	}}
+
+	repos, err := Sync(context.Background(), local, remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := byFullName(repos)
+	if len(merged) != 2 || !merged["acme/api"].ExistsLocal || merged["acme/api"].Affiliation != "owner" {
+		t.Errorf("Sync = %+v", repos)
+	}
+
+	failing := fakeProvider{name: "github", err: errors.New("bad credentials")}
+	if _, err := Sync(context.Background(), local, failing); err == nil || !strings.HasPrefix(err.Error(), "github: ") {
+		t.Errorf("Sync error = %v, want it prefixed with the provider", err)
+	}
+}
+
+func TestParseGitHubURL(t *testing.T) {
+	tests := []struct {
+		url         string
+		owner, name string
+		ok          bool
+	}{
+		{"git@github.com:acme/api.git", "acme", "api", true},
+		{"https://github.com/acme/api", "acme", "api", true},
+		{"https://gitlab.com/acme/api.git", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		owner, name, ok := ParseGitHubURL(tt.url)
+		if owner != tt.owner || name != tt.name || ok != tt.ok {
+			t.Errorf("ParseGitHubURL(%q) = %q, %q, %v, want %q, %q, %v", tt.url, owner, name, ok, tt.owner, tt.name, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package index
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// Store is the directory the index is kept in: repos.json holds the cached
+// repos and usage.json the usage history frecency is computed from.
+//
+// Writes are atomic, so loading never needs a lock. Saving and the Update
+// transactions hold the file's lock (see LockFile), so concurrent processes
+// don't lose each other's updates.
+type Store struct {
+	Dir string
+}
+
+// DefaultDir returns the directory fuzzyrepo keeps its index in
+func DefaultDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".local", "share", "fuzzyrepo")
+}
+
+// DefaultStore returns the store shared with fuzzyrepo
+func DefaultStore() Store {
+	return Store{Dir: DefaultDir()}
+}
+
+func (s Store) ReposPath() string {
+	return filepath.Join(s.Dir, "repos.json")
+}
+
+func (s Store) UsagePath() string {
+	return filepath.Join(s.Dir, "usage.json")
+}
+
+// LoadRepos returns the cached repos, or nil if there is no cache yet
+func (s Store) LoadRepos() ([]Repository, error) {
+	data, err := os.ReadFile(s.ReposPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var repos []Repository
+	if err := json.Unmarshal(data, &repos); err != nil {
+		return nil, err
+	}
+
+	for i := range repos {
+		repos[i].ComputeSearchText()
+	}
+
+	return repos, nil
+}
+
+// SaveRepos replaces the cached repos
+func (s Store) SaveRepos(repos []Repository) error {
+	return s.withLock(s.ReposPath(), func() error {
+		return s.writeJSON(s.ReposPath(), repos)
+	})
+}
+
+// UpdateRepos replaces the cached repos with what update returns for them,
+// holding the lock of repos.json throughout. Nothing is saved if update
+// fails.
+func (s Store) UpdateRepos(update func([]Repository) ([]Repository, error)) error {
+	return s.withLock(s.ReposPath(), func() error {
+		repos, err := s.LoadRepos()
+		if err != nil {
+			return err
+		}
+		if repos, err = update(repos); err != nil {
+			return err
+		}
+		return s.writeJSON(s.ReposPath(), repos)
+	})
+}
+
+// LoadUsage returns the usage history, empty if there is none yet
+func (s Store) LoadUsage() (UsageData, error) {
+	data, err := os.ReadFile(s.UsagePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return make(UsageData), nil
+		}
+		return nil, err
+	}
+
+	var usage UsageData
+	if err := json.Unmarshal(data, &usage); err != nil {
+		return nil, err
+	}
+
+	return usage, nil
+}
+
+func (s Store) SaveUsage(usage UsageData) error {
+	return s.withLock(s.UsagePath(), func() error {
+		return s.writeJSON(s.UsagePath(), usage)
+	})
+}
+
+// UpdateUsage changes the usage history in place with update, holding the
+// lock of usage.json throughout. Nothing is saved if update fails, or if
+// the history can't be read; a corrupt one is replaced.
+func (s Store) UpdateUsage(update func(UsageData) error) error {
+	return s.withLock(s.UsagePath(), func() error {
+		usage, err := s.LoadUsage()
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+			// A corrupt history is started over rather than kept forever
+			usage = make(UsageData)
+		case err != nil:
+			return err
+		}
+		if err := update(usage); err != nil {
+			return err
+		}
+		return s.writeJSON(s.UsagePath(), usage)
+	})
+}
+
+// RecordUsage counts a use of the repo named fullName in the usage history
+func (s Store) RecordUsage(fullName string) error {
+	return s.UpdateUsage(func(usage UsageData) error {
+		usage.Record(fullName)
+		return nil
+	})
+}
+
+// withLock runs fn holding the lock guarding the file at path
+func (s Store) withLock(path string, fn func() error) error {
+	lock, err := LockFile(path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	return fn()
+}
+
+// writeJSON writes v to path atomically, so readers never see a partial file
+func (s Store) writeJSON(path string, v any) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, b, 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
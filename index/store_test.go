@@ -0,0 +1,143 @@
+package index
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+func TestStoreRepos(t *testing.T) {
+	s := Store{Dir: t.TempDir()}
+
+	repos, err := s.LoadRepos()
+	if err != nil || repos != nil {
+		t.Fatalf("LoadRepos without a cache = %v, %v, want nil, nil", repos, err)
+	}
+
+	saved := []Repository{{Owner: "acme", Name: "api", FullName: "acme/api", Stars: 2}}
+	if err := s.SaveRepos(saved); err != nil {
+		t.Fatal(err)
+	}
+	repos, err = s.LoadRepos()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repos) != 1 || repos[0].FullName != "acme/api" || repos[0].Stars != 2 {
+		t.Fatalf("LoadRepos = %+v, want %+v", repos, saved)
+	}
+	if repos[0].SearchText == "" {
+		t.Error("loaded repos have no search text")
+	}
+
+	// A failed update saves nothing
+	failed := errors.New("failed")
+	err = s.UpdateRepos(func(repos []Repository) ([]Repository, error) {
+		return nil, failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("UpdateRepos = %v, want %v", err, failed)
+	}
+	err = s.UpdateRepos(func(repos []Repository) ([]Repository, error) {
+		return append(repos, Repository{FullName: "acme/web"}), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repos, _ = s.LoadRepos(); len(repos) != 2 {
+		t.Errorf("after the update there are %d repos, want 2", len(repos))
+	}
+}
+
+func TestStoreUsage(t *testing.T) {
+	s := Store{Dir: t.TempDir()}
+
+	usage, err := s.LoadUsage()
+	if err != nil || len(usage) != 0 {
+		t.Fatalf("LoadUsage without history = %v, %v, want empty", usage, err)
+	}
+
+	for range 3 {
+		if err := s.RecordUsage("Acme/API"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	usage, err = s.LoadUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := usage["acme/api"]; entry.Count != 3 || entry.LastUsedAt.IsZero() {
+		t.Errorf("usage of acme/api = %+v, want 3 uses", entry)
+	}
+
+	// A corrupt history is started over
+	if err := os.WriteFile(s.UsagePath(), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordUsage("acme/web"); err != nil {
+		t.Fatal(err)
+	}
+	if usage, _ = s.LoadUsage(); len(usage) != 1 || usage["acme/web"].Count != 1 {
+		t.Errorf("usage after a corrupt history = %v", usage)
+	}
+
+	// One that can't be read is kept
+	if err := os.Remove(s.UsagePath()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(s.UsagePath(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RecordUsage("acme/web"); err == nil {
+		t.Error("RecordUsage with an unreadable history succeeded")
+	}
+}
+
+// TestHelperRecordUsage records usage in a child process started by
+// TestRecordUsageConcurrently
+func TestHelperRecordUsage(t *testing.T) {
+	dir := os.Getenv("INDEX_TEST_STORE")
+	if dir == "" {
+		t.Skip("only run as a helper process")
+	}
+	s := Store{Dir: dir}
+	for range usesPerProcess {
+		if err := s.RecordUsage("acme/api"); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+const usesPerProcess = 50
+
+func TestRecordUsageConcurrently(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("locks are not enforced on Windows")
+	}
+	s := Store{Dir: t.TempDir()}
+
+	const processes = 8
+	cmds := make([]*exec.Cmd, processes)
+	for i := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHelperRecordUsage$")
+		cmd.Env = append(os.Environ(), "INDEX_TEST_STORE="+s.Dir)
+		if err := cmd.Start(); err != nil {
+			t.Fatal(err)
+		}
+		cmds[i] = cmd
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage, err := s.LoadUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := usage["acme/api"].Count; got != processes*usesPerProcess {
+		t.Errorf("recorded %d uses, want %d", got, processes*usesPerProcess)
+	}
+}
@@ -0,0 +1,48 @@
+package index
+
+import (
+	"context"
+	"fmt"
+)
+
+// Provider is a source of repositories, such as GitHub or local directories
+type Provider interface {
+	Name() string
+	// List returns all repos of the source. Repos with a local clone have
+	// ExistsLocal set, see MergeRepos.
+	List(ctx context.Context) ([]Repository, error)
+}
+
+// LocalProvider finds the git repos below its roots, see ScanLocal
+type LocalProvider struct {
+	Roots []string
+}
+
+func (p LocalProvider) Name() string {
+	return "local"
+}
+
+func (p LocalProvider) List(ctx context.Context) ([]Repository, error) {
+	return ScanLocal(p.Roots), nil
+}
+
+// Sync lists the repos of every provider and merges them: local clones are
+// matched with the remote repos of the same name. It fails on the first
+// provider error, so a partial result never replaces a complete index.
+func Sync(ctx context.Context, providers ...Provider) ([]Repository, error) {
+	var local, remote []Repository
+	for _, p := range providers {
+		repos, err := p.List(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.Name(), err)
+		}
+		for _, r := range repos {
+			if r.ExistsLocal {
+				local = append(local, r)
+			} else {
+				remote = append(remote, r)
+			}
+		}
+	}
+	return MergeRepos(local, remote), nil
+}
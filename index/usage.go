@@ -0,0 +1,64 @@
+package index
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+type UsageEntry struct {
+	Count      int       `json:"count"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// UsageData is the usage history, keyed by lowercase full name
+type UsageData map[string]UsageEntry
+
+// Record counts a use of the repo named fullName
+func (u UsageData) Record(fullName string) {
+	key := strings.ToLower(fullName)
+	entry := u[key]
+	entry.Count++
+	entry.LastUsedAt = time.Now()
+	u[key] = entry
+}
+
+// GetUsageBoost returns the frecency score of repo: how often it was used,
+// plus how recently with a half-life of a week. Unused repos score 0.
+func GetUsageBoost(usage UsageData, repo Repository) float64 {
+	key := strings.ToLower(repo.FullName)
+	entry, ok := usage[key]
+	if !ok || entry.Count == 0 {
+		return 0
+	}
+
+	freqScore := math.Log2(1 + float64(entry.Count))
+
+	daysSince := time.Since(entry.LastUsedAt).Hours() / 24
+	halfLifeDays := 7.0
+	recencyScore := math.Pow(0.5, daysSince/halfLifeDays)
+
+	return freqScore*1.5 + recencyScore*2.0
+}
+
+// SortByUsage returns the repos ordered by usage boost, most used first
+func SortByUsage(repos []Repository, usage UsageData) []Repository {
+	result := make([]Repository, len(repos))
+	copy(result, repos)
+
+	for i := 1; i < len(result); i++ {
+		j := i
+		for j > 0 {
+			boostJ := GetUsageBoost(usage, result[j])
+			boostJMinus1 := GetUsageBoost(usage, result[j-1])
+			if boostJ > boostJMinus1 {
+				result[j], result[j-1] = result[j-1], result[j]
+				j--
+			} else {
+				break
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Editor integrations, set with editor_integration
+const (
+	integrationAuto   = "auto" // Detect from the environment (default)
+	integrationNone   = "none" // Always use editor / $EDITOR
+	integrationNeovim = "neovim"
+	integrationEmacs  = "emacs"
+	integrationVSCode = "vscode"
+	integrationZed    = "zed"
+	integrationHelix  = "helix"
+)
+
+var ErrIntegrationFailed = errors.New("editor integration failed")
+
+// editorIntegration opens repos in an editor fuzzyrepo runs inside of (or,
+// for editors without a remote API, the way that editor expects) instead of
+// starting a nested editor
+type editorIntegration struct {
+	name   string
+	detect func(config Config) bool
+	open   func(path string, repo Repository, config Config) error
+}
+
+// editorIntegrations lists the integrations in detection order
+var editorIntegrations = []editorIntegration{
+	{integrationNeovim, detectNeovim, openNeovimIntegration},
+	{integrationEmacs, detectEmacs, openInEmacs},
+	{integrationVSCode, detectVSCode, openInVSCode},
+	{integrationZed, detectZed, openInZed},
+	{integrationHelix, detectHelix, openInHelix},
+}
+
+// integrationNames returns the valid editor_integration values
+func integrationNames() []string {
+	names := []string{integrationAuto, integrationNone}
+	for _, i := range editorIntegrations {
+		names = append(names, i.name)
+	}
+	return names
+}
+
+// findIntegration returns the integration to open repos with: the one named
+// by editor_integration, or the first one detected in auto mode
+func findIntegration(config Config) (editorIntegration, bool) {
+	switch config.EditorIntegration {
+	case integrationNone:
+		return editorIntegration{}, false
+	case "", integrationAuto:
+		for _, i := range editorIntegrations {
+			if i.detect(config) {
+				return i, true
+			}
+		}
+		return editorIntegration{}, false
+	}
+	for _, i := range editorIntegrations {
+		if i.name == config.EditorIntegration {
+			return i, true
+		}
+	}
+	return editorIntegration{}, false
+}
+
+// runIntegration runs an editor's remote command, which returns right away
+func runIntegration(name string, argv ...string) error {
+	out, err := exec.Command(argv[0], argv[1:]...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s: %s", ErrIntegrationFailed, name, msg)
+		}
+		return fmt.Errorf("%w: %s: %v", ErrIntegrationFailed, name, err)
+	}
+	return nil
+}
+
+// Neovim: the Neovim whose :terminal runs fuzzyrepo, see openInNeovim
+func detectNeovim(Config) bool {
+	return os.Getenv("NVIM") != ""
+}
+
+func openNeovimIntegration(path string, repo Repository, config Config) error {
+	addr := os.Getenv("NVIM")
+	if addr == "" {
+		return fmt.Errorf("%w: neovim: $NVIM is not set, run fuzzyrepo inside Neovim's :terminal", ErrIntegrationFailed)
+	}
+	return openInNeovim(path, repo, config.Neovim.OpenStrategy, addr)
+}
+
+// Emacs: $INSIDE_EMACS is set by vterm, term, shell and eshell buffers.
+// The repo is opened with project-switch-project through the Emacs server.
+func detectEmacs(Config) bool {
+	return os.Getenv("INSIDE_EMACS") != ""
+}
+
+func openInEmacs(path string, repo Repository, config Config) error {
+	// -n returns right away, the project command prompt waits in Emacs
+	return runIntegration(integrationEmacs, "emacsclient", "-n", "--eval",
+		fmt.Sprintf("(project-switch-project %s)", elispString(path)))
+}
+
+// elispString quotes s as an Emacs Lisp string literal
+func elispString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// VS Code: its integrated terminal sets TERM_PROGRAM=vscode. --reuse-window
+// opens the repo in the window the terminal belongs to.
+func detectVSCode(Config) bool {
+	return os.Getenv("TERM_PROGRAM") == "vscode"
+}
+
+func openInVSCode(path string, repo Repository, config Config) error {
+	return runIntegration(integrationVSCode, "code", "--reuse-window", path)
+}
+
+// Zed: its terminal sets TERM_PROGRAM=zed. The zed CLI hands the path to
+// the running Zed.
+func detectZed(Config) bool {
+	return os.Getenv("TERM_PROGRAM") == "zed"
+}
+
+func openInZed(path string, repo Repository, config Config) error {
+	return runIntegration(integrationZed, "zed", path)
+}
+
+// Helix has no remote API and its terminal sets nothing, so it is detected
+// when it is the editor (editor setting, or $EDITOR without one). It starts
+// in the repo, so the file picker and :cd are rooted there.
+func detectHelix(config Config) bool {
+	_, ok := helixBinary(config)
+	return ok
+}
+
+// helixBinary returns the configured editor command if it is Helix
+func helixBinary(config Config) (string, bool) {
+	argv := config.Editor
+	if len(argv) == 0 {
+		argv, _ = splitWords(os.Getenv("EDITOR"))
+	}
+	if len(argv) == 0 {
+		return "", false
+	}
+	name := filepath.Base(argv[0])
+	return argv[0], name == "hx" || name == "helix"
+}
+
+func openInHelix(path string, repo Repository, config Config) error {
+	name, ok := helixBinary(config)
+	if !ok {
+		name = "hx"
+	}
+
+	// "." opens the file picker in the working directory
+	cmd := exec.Command(name, ".")
+	cmd.Dir = path
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "FUZZYREPO=1")
+	return cmd.Run()
+}
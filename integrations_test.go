@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindIntegration(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		editor      []string
+		integration string
+		want        string // Empty for none
+	}{
+		{"neovim terminal", map[string]string{"NVIM": "/tmp/nvim.sock"}, nil, "", integrationNeovim},
+		{"neovim terminal with editor nvim", map[string]string{"NVIM": "/tmp/nvim.sock"}, []string{"nvim"}, "", integrationNeovim},
+		{"neovim first", map[string]string{"NVIM": "/tmp/nvim.sock", "TERM_PROGRAM": "vscode"}, nil, integrationAuto, integrationNeovim},
+		{"vscode terminal", map[string]string{"TERM_PROGRAM": "vscode"}, []string{"vim"}, "", integrationVSCode},
+		{"helix editor", nil, []string{"hx"}, "", integrationHelix},
+		{"helix from $EDITOR", map[string]string{"EDITOR": "hx"}, nil, "", integrationHelix},
+		{"nothing detected", nil, []string{"vim"}, "", ""},
+		{"detection off", map[string]string{"NVIM": "/tmp/nvim.sock"}, []string{"nvim"}, integrationNone, ""},
+		{"named integration", nil, nil, integrationZed, integrationZed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"NVIM", "INSIDE_EMACS", "TERM_PROGRAM", "EDITOR"} {
+				t.Setenv(key, tt.env[key])
+			}
+			config := Config{Editor: tt.editor, EditorIntegration: tt.integration}
+			got, ok := findIntegration(config)
+			if ok != (tt.want != "") || got.name != tt.want {
+				t.Errorf("findIntegration = %q, %v, want %q", got.name, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenInHelixStartsInRepo(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	// A fake hx that notes its working directory and arguments
+	hx := filepath.Join(dir, "hx")
+	script := "#!/bin/sh\necho \"$PWD $*\" > " + out + "\n"
+	if err := os.WriteFile(hx, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	repoPath := t.TempDir()
+
+	config := Config{Editor: []string{hx}}
+	if err := openInHelix(repoPath, Repository{}, config); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.TrimSpace(string(data)), repoPath+" ."; got != want {
+		t.Errorf("hx ran as %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,525 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyBinding documents one key (or key group) in a UI mode, and is what the
+// mode dispatches the key to: a key whose name (tea.KeyMsg.String()) is in
+// match runs handle, see dispatchKey.
+type keyBinding struct {
+	keys   string
+	desc   string
+	match  []string
+	handle func(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd)
+}
+
+// dispatchKey runs the binding matching msg. Reports false if none does.
+func dispatchKey(bindings []keyBinding, m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+	key := msg.String()
+	for _, kb := range bindings {
+		if kb.handle != nil && slices.Contains(kb.match, key) {
+			model, cmd := kb.handle(m, msg)
+			return model, cmd, true
+		}
+	}
+	return m, nil, false
+}
+
+// mainKeys are the bindings of the main view. Typed characters without a
+// binding go into the search query.
+func mainKeys() []keyBinding {
+	return []keyBinding{
+		{keys: "type", desc: "fuzzy search"},
+		{keys: "↑/↓", desc: "navigate results", match: []string{"up", "down"},
+			handle: func(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+				if msg.Type == tea.KeyUp && m.cursor > 0 {
+					m.cursor--
+				}
+				if msg.Type == tea.KeyDown && m.cursor < len(m.results)-1 {
+					m.cursor++
+				}
+				return m, nil
+			}},
+		{keys: "enter", desc: "open repo (clone if needed)", match: []string{"enter"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				return m.chooseRepo(ActionOpen)
+			}},
+		{keys: "tab", desc: "cycle sort mode", match: []string{"tab"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.cycleSortMode()
+				return m, nil
+			}},
+		{keys: "space", desc: "open command palette", match: []string{" "},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.showCommands = true
+				m.commandCursor = 0
+				return m, nil
+			}},
+		{keys: "backspace", desc: "delete last query character", match: []string{"backspace"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				if len(m.query) > 0 {
+					m.query = m.query[:len(m.query)-1]
+					m.applySearch()
+				}
+				return m, nil
+			}},
+		{keys: "esc", desc: "clear search, dismiss error, quit", match: []string{"esc"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				if m.query != "" {
+					m.query = ""
+					m.applySearch()
+					return m, nil
+				}
+				if m.message.IsPinned() {
+					m.clearMessage()
+					return m, nil
+				}
+				return m, tea.Quit
+			}},
+		{keys: "ctrl+l", desc: "show message log", match: []string{"ctrl+l"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.openMessageLog()
+				return m, nil
+			}},
+		{keys: "?/f1", desc: "show this help", match: []string{"?", "f1"}, handle: openHelpKey},
+		{keys: "ctrl+c", desc: "quit", match: []string{"ctrl+c"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				return m, tea.Quit
+			}},
+	}
+}
+
+// configKeys are the bindings of the config overlay. Other keys edit the
+// focused field.
+func configKeys() []keyBinding {
+	return []keyBinding{
+		{keys: "tab/↓", desc: "next field", match: []string{"tab", "down"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.focusConfigField((m.configFocus + 1) % cfgFieldCount)
+				return m, nil
+			}},
+		{keys: "shift+tab/↑", desc: "previous field", match: []string{"shift+tab", "up"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.focusConfigField((m.configFocus - 1 + cfgFieldCount) % cfgFieldCount)
+				return m, nil
+			}},
+		{keys: "enter", desc: "save config", match: []string{"enter"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				return m.saveConfig()
+			}},
+		{keys: "space", desc: "open config file in $EDITOR", match: []string{" "},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				return m, openConfigInEditor(m.config)
+			}},
+		{keys: "ctrl+r", desc: "edit and test clone rules", match: []string{"ctrl+r"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.openRuleEditor()
+				return m, nil
+			}},
+		{keys: "f1", desc: "show this help", match: []string{"f1"}, handle: openHelpKey},
+		{keys: "esc", desc: "close without saving", match: []string{"esc"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.showConfig = false
+				return m, nil
+			}},
+	}
+}
+
+// ruleListKeys are the bindings of the clone rule editor's rule list
+func ruleListKeys() []keyBinding {
+	return []keyBinding{
+		{keys: "↑/↓", desc: "select rule", match: []string{"up", "down"},
+			handle: func(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+				e := m.rules
+				if msg.Type == tea.KeyUp {
+					e.cursor = max(0, e.cursor-1)
+				} else {
+					e.cursor = clamp(e.cursor+1, 0, max(0, len(e.rules)-1))
+				}
+				return m, nil
+			}},
+		{keys: "a", desc: "add rule below the selected one", match: []string{"a"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				e := m.rules
+				at := 0
+				if len(e.rules) > 0 {
+					at = e.cursor + 1
+				}
+				e.rules = slices.Insert(e.rules, at, CloneRule{})
+				e.cursor = at
+				e.startEdit(true)
+				return m, nil
+			}},
+		{keys: "e/enter", desc: "edit the selected rule", match: []string{"e", "enter"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				if len(m.rules.rules) > 0 {
+					m.rules.startEdit(false)
+				}
+				return m, nil
+			}},
+		{keys: "d/delete", desc: "delete rule", match: []string{"d", "delete"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				return m.deleteRule()
+			}},
+		{keys: "shift+↑/↓ K/J", desc: "move rule up or down", match: []string{"shift+up", "shift+down", "K", "J"},
+			handle: func(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+				if k := msg.String(); k == "shift+up" || k == "K" {
+					m.rules.move(-1)
+				} else {
+					m.rules.move(1)
+				}
+				return m, nil
+			}},
+		{keys: "t/tab", desc: "edit the repo to test", match: []string{"t", "tab"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.rules.testing = true
+				m.rules.test.Focus()
+				return m, nil
+			}},
+		{keys: "w", desc: "save rules to config", match: []string{"w"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				if err := m.saveCloneRules(); err != nil {
+					m.setMessageWithHint(fmt.Sprintf("clone rules not saved: %v", err), ErrorLevel, "press e to fix the rule")
+					return m, nil
+				}
+				m.setMessage(fmt.Sprintf("%d clone rules saved", len(m.rules.rules)), InfoLevel)
+				m.rules = nil
+				return m, nil
+			}},
+		{keys: "f1", desc: "show this help", match: []string{"f1"}, handle: openHelpKey},
+		{keys: "esc", desc: "close without saving", match: []string{"esc"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				if m.rules.dirty {
+					m.setMessage("clone rule changes discarded", InfoLevel)
+				}
+				m.rules = nil
+				return m, nil
+			}},
+		{keys: "ctrl+c", desc: "quit", match: []string{"ctrl+c"}, handle: quitKey},
+	}
+}
+
+// ruleEditKeys are the bindings while a clone rule is edited. Other keys
+// edit the focused field.
+func ruleEditKeys() []keyBinding {
+	return []keyBinding{
+		{keys: "tab/↓", desc: "next field", match: []string{"tab", "down"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				e := m.rules
+				e.field = (e.field + 1) % ruleFieldCount
+				e.focusField()
+				return m, nil
+			}},
+		{keys: "shift+tab/↑", desc: "previous field", match: []string{"shift+tab", "up"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				e := m.rules
+				e.field = (e.field - 1 + ruleFieldCount) % ruleFieldCount
+				e.focusField()
+				return m, nil
+			}},
+		{keys: "enter", desc: "keep the edited rule", match: []string{"enter"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				e := m.rules
+				rule := e.candidate()
+				if rule.Validate() != nil {
+					// The error is already shown below the inputs
+					return m, nil
+				}
+				e.rules[e.cursor] = rule
+				e.dirty = true
+				e.stopEdit()
+				return m, nil
+			}},
+		{keys: "f1", desc: "show this help", match: []string{"f1"}, handle: openHelpKey},
+		{keys: "esc", desc: "cancel edit", match: []string{"esc"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				e := m.rules
+				if e.adding {
+					e.rules = slices.Delete(e.rules, e.cursor, e.cursor+1)
+					e.cursor = clamp(e.cursor, 0, max(0, len(e.rules)-1))
+				}
+				e.stopEdit()
+				return m, nil
+			}},
+		{keys: "ctrl+c", desc: "quit", match: []string{"ctrl+c"}, handle: quitKey},
+	}
+}
+
+// ruleTestKeys are the bindings while the test repo of the clone rule
+// editor is edited. Other keys edit it.
+func ruleTestKeys() []keyBinding {
+	return []keyBinding{
+		{keys: "esc/enter/tab", desc: "back to the rules", match: []string{"esc", "enter", "tab"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.rules.testing = false
+				m.rules.test.Blur()
+				return m, nil
+			}},
+		{keys: "f1", desc: "show this help", match: []string{"f1"}, handle: openHelpKey},
+		{keys: "ctrl+c", desc: "quit", match: []string{"ctrl+c"}, handle: quitKey},
+	}
+}
+
+// manualPathKeys are the bindings of the enter path prompt. Other keys edit
+// the path.
+func manualPathKeys() []keyBinding {
+	return []keyBinding{
+		{keys: "enter", desc: "open path in editor", match: []string{"enter"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				path := strings.TrimSpace(m.manualPathInput.Value())
+				m.showManualPath = false
+				if path == "" {
+					return m, nil
+				}
+				m.selectedPath = path
+				m.selectedAction = ActionOpenPath
+				return m, tea.Quit
+			}},
+		{keys: "f1", desc: "show this help", match: []string{"f1"}, handle: openHelpKey},
+		{keys: "esc", desc: "cancel", match: []string{"esc"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.showManualPath = false
+				return m, nil
+			}},
+	}
+}
+
+// cloneKeys are the bindings while a repo is cloned in the UI. Other keys
+// are ignored.
+func cloneKeys() []keyBinding {
+	return []keyBinding{
+		{keys: "esc", desc: "cancel clone and remove the partial directory, or skip the remaining post-clone hooks", match: []string{"esc"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.clone.cancel()
+				return m, nil
+			}},
+		{keys: "ctrl+c", desc: "cancel clone and quit", match: []string{"ctrl+c"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.clone.quitting = true
+				m.clone.cancel()
+				return m, nil
+			}},
+	}
+}
+
+// messageLogKeys are the bindings of the message log
+func messageLogKeys() []keyBinding {
+	return []keyBinding{
+		{keys: "↑/↓ pgup/pgdn", desc: "scroll", match: []string{"up", "down", "pgup", "pgdown"},
+			handle: func(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.logScroll = scrollBy(msg, m.logScroll, m.logViewHeight(), len(m.messages.entries))
+				return m, nil
+			}},
+		{keys: "esc/q/ctrl+l", desc: "close log", match: []string{"esc", "q", "ctrl+l"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.showLog = false
+				return m, nil
+			}},
+		{keys: "ctrl+c", desc: "quit", match: []string{"ctrl+c"}, handle: quitKey},
+	}
+}
+
+// helpKeys are the bindings of the help overlay. Other typed characters
+// filter the bindings.
+func helpKeys() []keyBinding {
+	return []keyBinding{
+		{keys: "type", desc: "filter bindings"},
+		{keys: "↑/↓ pgup/pgdn", desc: "scroll", match: []string{"up", "down", "pgup", "pgdown"},
+			handle: func(m Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+				m.helpScroll = scrollBy(msg, m.helpScroll, m.helpViewHeight(), len(m.helpLines()))
+				return m, nil
+			}},
+		{keys: "backspace", desc: "delete last filter character", match: []string{"backspace"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				if len(m.helpQuery) > 0 {
+					m.helpQuery = m.helpQuery[:len(m.helpQuery)-1]
+					m.helpScroll = 0
+				}
+				return m, nil
+			}},
+		{keys: "esc", desc: "clear filter, close if empty", match: []string{"esc"},
+			handle: func(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+				if m.helpQuery != "" {
+					m.helpQuery = ""
+					m.helpScroll = 0
+					return m, nil
+				}
+				m.showHelp = false
+				return m, nil
+			}},
+		{keys: "ctrl+c", desc: "quit", match: []string{"ctrl+c"}, handle: quitKey},
+	}
+}
+
+// scrollBy returns scroll moved by an up, down, pgup or pgdown key, kept
+// within a list of total lines shown height at a time
+func scrollBy(msg tea.KeyMsg, scroll, height, total int) int {
+	switch msg.Type {
+	case tea.KeyUp:
+		scroll--
+	case tea.KeyDown:
+		scroll++
+	case tea.KeyPgUp:
+		scroll -= height
+	case tea.KeyPgDown:
+		scroll += height
+	}
+	return clamp(scroll, 0, max(0, total-height))
+}
+
+func quitKey(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m, tea.Quit
+}
+
+func openHelpKey(m Model, _ tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.openHelp()
+	return m, nil
+}
+
+// keymapSection groups the bindings of one UI mode
+type keymapSection struct {
+	mode     string
+	bindings []keyBinding
+}
+
+// keymap is the single registry of keybindings shown in the help overlay.
+// Each view's bindings are the table its keys are dispatched from, and
+// palette and filter entries are generated from their command tables, so
+// the help can't drift from what the keys actually do.
+func (m *Model) keymap() []keymapSection {
+	palette := []keyBinding{
+		{keys: "↑/↓", desc: "navigate"},
+		{keys: "enter", desc: "run selected command"},
+		{keys: "esc/space", desc: "close palette"},
+		{keys: "?", desc: "show this help"},
+	}
+	for _, cmd := range m.getCommands() {
+		palette = append(palette, keyBinding{keys: cmd.key, desc: cmd.name})
+	}
+
+	filters := []keyBinding{
+		{keys: "↑/↓", desc: "navigate"},
+		{keys: "enter", desc: "toggle selected filter"},
+	}
+	for _, t := range filterToggles {
+		filters = append(filters, keyBinding{keys: t.key, desc: t.name})
+	}
+	filters = append(filters,
+		keyBinding{keys: "w", desc: "save filters to config"},
+		keyBinding{keys: "esc/space", desc: "close filters"},
+	)
+
+	return []keymapSection{
+		{mode: "Main", bindings: mainKeys()},
+		{mode: "Command palette", bindings: palette},
+		{mode: "Filters", bindings: filters},
+		{mode: "Config", bindings: configKeys()},
+		{mode: "Clone rules", bindings: ruleListKeys()},
+		{mode: "Clone rule edit", bindings: ruleEditKeys()},
+		{mode: "Clone rule test repo", bindings: ruleTestKeys()},
+		{mode: "Enter path", bindings: manualPathKeys()},
+		{mode: "Cloning", bindings: cloneKeys()},
+		{mode: "Message log", bindings: messageLogKeys()},
+		{mode: "Help", bindings: helpKeys()},
+	}
+}
+
+// helpLines renders the keymap as plain lines, keeping only bindings that
+// match the filter query (mode name, keys or description)
+func (m *Model) helpLines() []string {
+	query := strings.ToLower(strings.TrimSpace(m.helpQuery))
+	modeStyle := inputTextStyle
+	keyStyle := keybindStyle.Width(16)
+	descStyle := repoNameStyle
+
+	var lines []string
+	for _, section := range m.keymap() {
+		sectionMatches := query != "" && strings.Contains(strings.ToLower(section.mode), query)
+
+		var bindings []string
+		for _, kb := range section.bindings {
+			text := strings.ToLower(kb.keys + " " + kb.desc)
+			if query != "" && !sectionMatches && !strings.Contains(text, query) {
+				continue
+			}
+			bindings = append(bindings, bgOnlyStyle.Render("  ")+keyStyle.Render(kb.keys)+descStyle.Render(kb.desc))
+		}
+		if len(bindings) == 0 {
+			continue
+		}
+
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, modeStyle.Render(section.mode))
+		lines = append(lines, bindings...)
+	}
+	return lines
+}
+
+// helpViewHeight is the number of keymap lines visible at once
+func (m *Model) helpViewHeight() int {
+	height := m.height
+	if height == 0 {
+		height = 24
+	}
+	// Border(2) + title(1) + filter(1) + blank lines(2) + hint(1)
+	return max(3, height-8)
+}
+
+func (m *Model) openHelp() {
+	m.showHelp = true
+	m.helpQuery = ""
+	m.helpScroll = 0
+}
+
+func (m Model) updateHelp(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if model, cmd, ok := dispatchKey(helpKeys(), m, msg); ok {
+		return model, cmd
+	}
+
+	// Anything else typed filters the bindings
+	switch msg.Type {
+	case tea.KeySpace, tea.KeyRunes:
+		m.helpQuery += msg.String()
+		m.helpScroll = 0
+	}
+	return m, nil
+}
+
+func (m Model) buildHelpBox() string {
+	all := m.helpLines()
+	viewH := m.helpViewHeight()
+	scroll := clamp(m.helpScroll, 0, max(0, len(all)-viewH))
+	end := min(len(all), scroll+viewH)
+
+	var lines []string
+	lines = append(lines, inputTextStyle.Render("Keybindings"))
+
+	filter := promptStyle.Render("/ ") + queryStyle.Render(m.helpQuery)
+	if m.helpQuery == "" {
+		filter += dimStyle.Render("type to filter")
+	}
+	lines = append(lines, filter)
+	lines = append(lines, "")
+
+	if len(all) == 0 {
+		lines = append(lines, dimStyle.Render("no matching bindings"))
+	} else {
+		lines = append(lines, all[scroll:end]...)
+	}
+
+	lines = append(lines, "")
+	hints := keybindStyle.Render("↑↓ scroll  type filter  esc close")
+	if len(all) > viewH {
+		hints += dimStyle.Render(fmt.Sprintf("  (%d-%d of %d)", scroll+1, end, len(all)))
+	}
+	lines = append(lines, hints)
+
+	return overlayStyle.Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/wealthystudent/fuzzyrepo/index"
+)
+
+// keyEvents are the key events the dispatched bindings can match, by name
+var keyEvents = []tea.KeyMsg{
+	{Type: tea.KeyUp},
+	{Type: tea.KeyDown},
+	{Type: tea.KeyEnter},
+	{Type: tea.KeyTab},
+	{Type: tea.KeyShiftTab},
+	{Type: tea.KeySpace},
+	{Type: tea.KeyBackspace},
+	{Type: tea.KeyEsc},
+	{Type: tea.KeyCtrlC},
+	{Type: tea.KeyCtrlL},
+	{Type: tea.KeyCtrlR},
+	{Type: tea.KeyF1},
+	{Type: tea.KeyPgUp},
+	{Type: tea.KeyPgDown},
+	{Type: tea.KeyDelete},
+	{Type: tea.KeyShiftUp},
+	{Type: tea.KeyShiftDown},
+}
+
+func init() {
+	for _, r := range "?aedKJtwq" {
+		keyEvents = append(keyEvents, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func testModel(t *testing.T) Model {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	return newModel(nil, DefaultConfig(), nil, time.Time{}, false)
+}
+
+// testRuleEditor returns a model with the clone rule editor open on one rule
+func testRuleEditor(t *testing.T) Model {
+	m := testModel(t)
+	m.config.CloneRules = []CloneRule{{CloneRule: index.CloneRule{Pattern: "^acme/", Path: "/src/acme"}}}
+	m.openRuleEditor()
+	return m
+}
+
+// Every documented key of a view must be a real key name with a handler,
+// so the help can't list a dead key
+func TestKeymapBindingsAreDispatched(t *testing.T) {
+	events := make(map[string]tea.KeyMsg)
+	for _, msg := range keyEvents {
+		events[msg.String()] = msg
+	}
+
+	sections := []struct {
+		mode     string
+		bindings []keyBinding
+		model    func(t *testing.T) Model
+	}{
+		{"main", mainKeys(), testModel},
+		{"config", configKeys(), testModel},
+		{"clone rules", ruleListKeys(), testRuleEditor},
+		{"clone rule edit", ruleEditKeys(), func(t *testing.T) Model {
+			m := testRuleEditor(t)
+			m.rules.startEdit(false)
+			return m
+		}},
+		{"clone rule test", ruleTestKeys(), testRuleEditor},
+		{"enter path", manualPathKeys(), testModel},
+		{"cloning", cloneKeys(), func(t *testing.T) Model {
+			m := testModel(t)
+			m.clone = &cloneJob{cancel: func() {}}
+			return m
+		}},
+		{"message log", messageLogKeys(), testModel},
+		{"help", helpKeys(), testModel},
+	}
+	for _, section := range sections {
+		for i, kb := range section.bindings {
+			if kb.keys == "type" {
+				continue // Falls through to the query or filter
+			}
+			if kb.handle == nil || len(kb.match) == 0 {
+				t.Errorf("%s %q: no handler", section.mode, kb.keys)
+				continue
+			}
+			for _, key := range kb.match {
+				msg, ok := events[key]
+				if !ok {
+					t.Errorf("%s %q: %q is not a known key name", section.mode, kb.keys, key)
+					continue
+				}
+				// The first binding matching the key must be this one
+				for j, other := range section.bindings[:i] {
+					for _, k := range other.match {
+						if k == key {
+							t.Errorf("%s %q: %q is taken by binding %d", section.mode, kb.keys, key, j)
+						}
+					}
+				}
+				if _, _, handled := dispatchKey(section.bindings, section.model(t), msg); !handled {
+					t.Errorf("%s %q: %q is not dispatched", section.mode, kb.keys, key)
+				}
+			}
+		}
+	}
+}
+
+func TestMainKeysUpdateModel(t *testing.T) {
+	m := testModel(t)
+	m.query = "api"
+
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if got := model.(Model).query; got != "ap" {
+		t.Errorf("backspace: query = %q, want %q", got, "ap")
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if got := model.(Model).query; got != "apx" {
+		t.Errorf("typing: query = %q, want %q", got, "apx")
+	}
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeySpace})
+	if !model.(Model).showCommands {
+		t.Error("space did not open the command palette")
+	}
+
+	model, _ = testModel(t).Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if !model.(Model).showHelp {
+		t.Error("? did not open the help")
+	}
+}
+
+func TestKeymapHasNoDuplicateKeys(t *testing.T) {
+	m := testModel(t)
+	for _, section := range m.keymap() {
+		seen := make(map[string]bool)
+		for _, kb := range section.bindings {
+			if seen[kb.keys] {
+				t.Errorf("%s lists %q twice", section.mode, kb.keys)
+			}
+			seen[kb.keys] = true
+		}
+	}
+}
+
+func TestOverlayKeysUpdateModel(t *testing.T) {
+	m := testModel(t)
+	m.openMessageLog()
+	model, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if model.(Model).showLog {
+		t.Error("q did not close the message log")
+	}
+
+	m = testModel(t)
+	m.openHelp()
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("clone")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeySpace})
+	if got := model.(Model).helpQuery; got != "clone " {
+		t.Errorf("help filter = %q, want %q", got, "clone ")
+	}
+
+	m = testRuleEditor(t)
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	if e := model.(Model).rules; !e.editing || len(e.rules) != 2 || e.cursor != 1 {
+		t.Errorf("a did not add a rule below the selected one: %+v", e)
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if e := model.(Model).rules; e.editing || len(e.rules) != 1 {
+		t.Errorf("esc did not drop the added rule: %+v", e)
+	}
+}
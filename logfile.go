@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// maxLogSize is the size at which a log file in the cache dir is rotated
+const maxLogSize = 1 << 20 // 1 MiB
+
+// appendLog appends text to the log file at path. Once the file grows past
+// maxLogSize it is moved to path + ".1", replacing the previous rotation,
+// so a log never takes more than about twice maxLogSize.
+func appendLog(path, text string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Size() > maxLogSize {
+		_ = os.Rename(path, path+".1")
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
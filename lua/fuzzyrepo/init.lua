@@ -5,6 +5,17 @@ local defaults = {
 	height = 0.4,
 	border = "rounded",
 	cmd = "fuzzyrepo",
+	-- How a picked repo opens: "tab", "cd", "split", "vsplit" or "instance".
+	-- neovim.open_strategy in the fuzzyrepo config takes precedence.
+	open_strategy = "tab",
+	-- Ex command, or function(path, repo), run after opening a repo; false disables
+	explorer = "NvimTreeOpen",
+	session = {
+		enabled = false,
+		dir = vim.fn.stdpath("state") .. "/fuzzyrepo/sessions",
+	},
+	-- Picker for :FuzzyrepoPick: "telescope", "fzf-lua", "snacks" or "auto"
+	picker = "auto",
 }
 
 M.config = {}
@@ -13,6 +24,13 @@ function M.setup(opts)
 	M.config = vim.tbl_deep_extend("force", defaults, opts or {})
 end
 
+local function config()
+	if not M.config.cmd then
+		M.setup({})
+	end
+	return M.config
+end
+
 local function create_float_win(width_pct, height_pct)
 	local width = math.floor(vim.o.columns * width_pct)
 	local height = math.floor(vim.o.lines * height_pct)
@@ -34,11 +52,13 @@ local function create_float_win(width_pct, height_pct)
 	return buf, win
 end
 
+-- Window the picker was opened from, where repos are opened
+local origin_win
+
 function M.open()
-	if not M.config.cmd then
-		M.setup({})
-	end
+	config()
 
+	origin_win = vim.api.nvim_get_current_win()
 	local buf, win = create_float_win(M.config.width, M.config.height)
 
 	local env = {
@@ -60,6 +80,12 @@ function M.open()
 	vim.cmd("startinsert")
 end
 
+-- Shows the repos in a native picker instead of the TUI, see fuzzyrepo.picker
+function M.pick(opts)
+	config()
+	require("fuzzyrepo.picker").pick(opts)
+end
+
 local function normalize_path(path)
 	if not path or path == "" then
 		return ""
@@ -69,25 +95,185 @@ local function normalize_path(path)
 	return real or p
 end
 
-function M.open_repo(path)
-	local target = normalize_path(path)
-	if target == "" then
+-- Repos opened in this Neovim, by normalized path, for saving their sessions
+local opened = {}
+
+local function session_file(root)
+	local name = root:gsub("[/\\:]", "%%")
+	return config().session.dir .. "/" .. name .. ".json"
+end
+
+local function in_root(name, root)
+	return name ~= "" and vim.startswith(normalize_path(name), root .. "/")
+end
+
+-- Saves the listed file buffers below root, and the current one with its cursor
+function M.save_session(root)
+	if not config().session.enabled or root == "" then
+		return
+	end
+	local files = {}
+	for _, buf in ipairs(vim.api.nvim_list_bufs()) do
+		local name = vim.api.nvim_buf_get_name(buf)
+		if vim.bo[buf].buflisted and vim.bo[buf].buftype == "" and in_root(name, root) then
+			table.insert(files, name)
+		end
+	end
+	if #files == 0 then
 		return
 	end
+
+	local session = { files = files }
+	local current = vim.api.nvim_buf_get_name(0)
+	if in_root(current, root) then
+		session.current = current
+		session.cursor = vim.api.nvim_win_get_cursor(0)
+	end
+
+	vim.fn.mkdir(config().session.dir, "p")
+	vim.fn.writefile({ vim.json.encode(session) }, session_file(root))
+end
+
+-- Reloads the buffers saved for root; returns true if a session was restored
+local function restore_session(root)
+	if not config().session.enabled then
+		return false
+	end
+	local ok, lines = pcall(vim.fn.readfile, session_file(root))
+	if not ok or #lines == 0 then
+		return false
+	end
+	local decoded, session = pcall(vim.json.decode, table.concat(lines, "\n"))
+	if not decoded or type(session.files) ~= "table" then
+		return false
+	end
+
+	local restored = false
+	for _, file in ipairs(session.files) do
+		if vim.fn.filereadable(file) == 1 then
+			vim.cmd("badd " .. vim.fn.fnameescape(file))
+			restored = true
+		end
+	end
+	if session.current and vim.fn.filereadable(session.current) == 1 then
+		vim.cmd("edit " .. vim.fn.fnameescape(session.current))
+		pcall(vim.api.nvim_win_set_cursor, 0, session.cursor)
+	end
+	return restored
+end
+
+local function open_explorer(target, repo)
+	local explorer = config().explorer
+	if not explorer then
+		return
+	end
+	vim.schedule(function()
+		if type(explorer) == "function" then
+			pcall(explorer, target, repo)
+		else
+			pcall(vim.cmd, explorer)
+		end
+	end)
+end
+
+local function find_tab(target)
 	for _, tab in ipairs(vim.api.nvim_list_tabpages()) do
 		local tabnr = vim.api.nvim_tabpage_get_number(tab)
-		local tabcwd = normalize_path(vim.fn.getcwd(-1, tabnr))
-		if tabcwd == target then
+		if normalize_path(vim.fn.getcwd(-1, tabnr)) == target then
+			return tabnr
+		end
+	end
+end
+
+-- Each strategy opens target; returns false if it switched to an existing
+-- place for the repo instead, so nothing needs restoring
+local strategies = {
+	tab = function(target, repo)
+		local tabnr = find_tab(target)
+		if tabnr then
 			vim.cmd("tabnext " .. tabnr)
-			return
+			return false
+		end
+		vim.cmd("tabnew")
+		vim.cmd("tcd " .. vim.fn.fnameescape(target))
+		vim.t.tabname = repo.name or vim.fn.fnamemodify(target, ":t")
+		return true
+	end,
+	cd = function(target)
+		M.save_session(normalize_path(vim.fn.getcwd()))
+		vim.cmd("cd " .. vim.fn.fnameescape(target))
+		return true
+	end,
+	split = function(target)
+		vim.cmd("split")
+		vim.cmd("lcd " .. vim.fn.fnameescape(target))
+		return true
+	end,
+	vsplit = function(target)
+		vim.cmd("vsplit")
+		vim.cmd("lcd " .. vim.fn.fnameescape(target))
+		return true
+	end,
+	instance = function(target, repo)
+		vim.cmd("tabnew")
+		vim.fn.termopen({ vim.v.progpath }, { cwd = target })
+		vim.t.tabname = repo.name or vim.fn.fnamemodify(target, ":t")
+		vim.cmd("startinsert")
+		return false
+	end,
+}
+
+-- Opens the repo at path. opts is sent by fuzzyrepo:
+--   strategy: one of the strategies above, overriding config().open_strategy
+--   repo: { name, full_name, owner, affiliation, language, ssh_url, web_url }
+function M.open_repo(path, opts)
+	opts = opts or {}
+	local repo = opts.repo or {}
+	local target = normalize_path(path)
+	if target == "" then
+		return
+	end
+	-- Called over RPC while the picker terminal may still be in terminal mode
+	vim.cmd("stopinsert")
+	-- Open from the window below the picker float, which can't be split
+	if vim.api.nvim_win_get_config(0).relative ~= "" and origin_win and vim.api.nvim_win_is_valid(origin_win) then
+		vim.api.nvim_set_current_win(origin_win)
+	end
+
+	local name = opts.strategy or config().open_strategy
+	local strategy = strategies[name]
+	if not strategy then
+		error("fuzzyrepo: unknown open strategy " .. vim.inspect(name))
+	end
+
+	if strategy(target, repo) then
+		opened[target] = true
+		if not restore_session(target) then
+			open_explorer(target, repo)
 		end
 	end
-	vim.cmd("tabnew")
-	vim.cmd("tcd " .. vim.fn.fnameescape(target))
-	-- Open file explorer for new repo tabs
-	vim.schedule(function()
-		pcall(vim.cmd, "NvimTreeOpen")
-	end)
 end
 
+local group = vim.api.nvim_create_augroup("fuzzyrepo_sessions", { clear = true })
+
+-- Save a repo's buffers when leaving its tab and when Neovim exits
+vim.api.nvim_create_autocmd("TabLeave", {
+	group = group,
+	callback = function()
+		local root = normalize_path(vim.fn.getcwd(-1, 0))
+		if opened[root] then
+			M.save_session(root)
+		end
+	end,
+})
+
+vim.api.nvim_create_autocmd("VimLeavePre", {
+	group = group,
+	callback = function()
+		for root in pairs(opened) do
+			M.save_session(root)
+		end
+	end,
+})
+
 return M
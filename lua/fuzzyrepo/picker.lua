@@ -0,0 +1,200 @@
+-- Native pickers over the fuzzyrepo index. Repos come from
+-- `fuzzyrepo list --json` (already ranked by frecency), and picking one runs
+-- `fuzzyrepo ensure`, which clones it if needed and records the usage, so
+-- the TUI and the pickers share one frecency history. The repo then opens
+-- with the same open strategy and pre_open/post_open hooks as in the TUI.
+local M = {}
+
+local function fuzzyrepo()
+	return require("fuzzyrepo")
+end
+
+-- Returns the repos of the index, best first, or nil and an error message
+local function list()
+	local out = vim.fn.system({ fuzzyrepo().config.cmd, "list", "--json" })
+	if vim.v.shell_error ~= 0 then
+		return nil, vim.trim(out)
+	end
+	local ok, repos = pcall(vim.json.decode, out)
+	if not ok or type(repos) ~= "table" then
+		return nil, "unexpected output from fuzzyrepo list"
+	end
+	return repos
+end
+
+local function label(repo)
+	return repo.full_name
+end
+
+-- Shows the "Warning: ..." lines fuzzyrepo prints for failed hooks
+local function warn_hooks(lines)
+	local warnings = {}
+	for _, line in ipairs(lines) do
+		if vim.startswith(line, "Warning:") or vim.startswith(line, "(hook output") then
+			table.insert(warnings, line)
+		end
+	end
+	if #warnings > 0 then
+		vim.notify("fuzzyrepo: " .. table.concat(warnings, "\n"), vim.log.levels.WARN)
+	end
+end
+
+-- Clones the repo if needed, then opens it like the TUI does: pre_open
+-- hooks, the open strategy of the fuzzyrepo config, then post_open hooks
+local function choose(repo)
+	if not repo then
+		return
+	end
+	if not repo.exists_local then
+		vim.notify("fuzzyrepo: cloning " .. repo.full_name .. "...")
+	end
+
+	local cmd = fuzzyrepo().config.cmd
+	local stdout, stderr = {}, {}
+	vim.fn.jobstart({ cmd, "ensure", "--json", "--pre-open", repo.full_name }, {
+		stdout_buffered = true,
+		stderr_buffered = true,
+		on_stdout = function(_, data)
+			stdout = data
+		end,
+		on_stderr = function(_, data)
+			stderr = data
+		end,
+		on_exit = function(_, code)
+			vim.schedule(function()
+				if code ~= 0 then
+					local msg = vim.trim(table.concat(stderr, "\n"))
+					vim.notify("fuzzyrepo: " .. msg, vim.log.levels.ERROR)
+					return
+				end
+				warn_hooks(stderr)
+
+				local ok, entry = pcall(vim.json.decode, table.concat(stdout, "\n"))
+				if not ok or type(entry) ~= "table" or not entry.local_path then
+					vim.notify("fuzzyrepo: unexpected output from fuzzyrepo ensure", vim.log.levels.ERROR)
+					return
+				end
+				-- Like neovim.open_strategy for the TUI, the config wins over
+				-- the plugin setting
+				fuzzyrepo().open_repo(entry.local_path, { repo = entry, strategy = entry.open_strategy })
+
+				vim.fn.jobstart({ cmd, "hook", "--path", entry.local_path, "post_open", entry.full_name }, {
+					stderr_buffered = true,
+					on_stderr = function(_, data)
+						vim.schedule(function()
+							warn_hooks(data)
+						end)
+					end,
+				})
+			end)
+		end,
+	})
+end
+
+local backends = {}
+
+function backends.telescope(repos, opts)
+	local pickers = require("telescope.pickers")
+	local finders = require("telescope.finders")
+	local conf = require("telescope.config").values
+	local actions = require("telescope.actions")
+	local action_state = require("telescope.actions.state")
+
+	pickers
+		.new(opts, {
+			prompt_title = "Repos",
+			finder = finders.new_table({
+				results = repos,
+				entry_maker = function(repo)
+					return { value = repo, display = label(repo), ordinal = repo.full_name }
+				end,
+			}),
+			sorter = conf.generic_sorter(opts),
+			attach_mappings = function(bufnr)
+				actions.select_default:replace(function()
+					local entry = action_state.get_selected_entry()
+					actions.close(bufnr)
+					choose(entry and entry.value)
+				end)
+				return true
+			end,
+		})
+		:find()
+end
+
+backends["fzf-lua"] = function(repos, opts)
+	local by_label, lines = {}, {}
+	for _, repo in ipairs(repos) do
+		by_label[label(repo)] = repo
+		table.insert(lines, label(repo))
+	end
+
+	require("fzf-lua").fzf_exec(
+		lines,
+		vim.tbl_extend("keep", opts, {
+			prompt = "Repos> ",
+			actions = {
+				default = function(selected)
+					choose(selected and by_label[selected[1]])
+				end,
+			},
+		})
+	)
+end
+
+function backends.snacks(repos, opts)
+	local items = {}
+	for _, repo in ipairs(repos) do
+		table.insert(items, { text = label(repo), repo = repo })
+	end
+
+	require("snacks").picker.pick(vim.tbl_extend("keep", opts, {
+		title = "Repos",
+		items = items,
+		format = function(item)
+			return { { item.text } }
+		end,
+		confirm = function(picker, item)
+			picker:close()
+			choose(item and item.repo)
+		end,
+	}))
+end
+
+-- Backends in the order "auto" tries them
+local order = { "telescope", "fzf-lua", "snacks" }
+
+-- Shows the repos in a picker. opts.backend is "telescope", "fzf-lua",
+-- "snacks" or "auto" (the default: the first one installed); other opts are
+-- passed to the backend.
+function M.pick(opts)
+	opts = vim.deepcopy(opts or {})
+	local backend = opts.backend or fuzzyrepo().config.picker or "auto"
+	opts.backend = nil
+
+	if backend == "auto" then
+		backend = nil
+		for _, name in ipairs(order) do
+			if pcall(require, name) then
+				backend = name
+				break
+			end
+		end
+		if not backend then
+			vim.notify("fuzzyrepo: no picker found, install telescope, fzf-lua or snacks", vim.log.levels.ERROR)
+			return
+		end
+	elseif not backends[backend] then
+		vim.notify("fuzzyrepo: unknown picker " .. vim.inspect(backend), vim.log.levels.ERROR)
+		return
+	end
+
+	local repos, err = list()
+	if not repos then
+		vim.notify("fuzzyrepo: " .. err, vim.log.levels.ERROR)
+		return
+	end
+	backends[backend](repos, opts)
+end
+
+return M
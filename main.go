@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -17,6 +18,26 @@ func main() {
 		return
 	}
 
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "rules":
+			// "fuzzyrepo rules test owner/repo" explains which clone rule applies
+			os.Exit(runRulesCommand(os.Args[2:]))
+		case "list":
+			// Machine-readable index for editor pickers and scripts
+			os.Exit(runListCommand(os.Args[2:]))
+		case "ensure":
+			// Clone if needed and record usage, for editor pickers and scripts
+			os.Exit(runEnsureCommand(os.Args[2:]))
+		case "hook":
+			// Run open hooks for repos opened by editor pickers
+			os.Exit(runHookCommand(os.Args[2:]))
+		case "daemon":
+			// Optional background process keeping the index in memory
+			os.Exit(runDaemonCommand(os.Args[2:]))
+		}
+	}
+
 	// Check if this is first run (no config file exists)
 	firstRun := IsFirstRun()
 
@@ -36,7 +57,16 @@ func main() {
 	uiMsgs := make(chan tea.Msg, 10)
 	refreshChan := make(chan struct{}, 1)
 
-	initial, err := loadRepoCache()
+	// A running daemon has the index in memory and schedules syncs itself
+	useDaemon := daemonRunning()
+
+	var initial []Repository
+	if useDaemon {
+		initial, err = daemonRepos()
+	}
+	if !useDaemon || err != nil {
+		initial, err = loadRepoCache()
+	}
 	if err != nil {
 		log.Println("Warning: could not load repo cache:", err)
 	}
@@ -44,13 +74,16 @@ func main() {
 	// Load metadata to check sync status
 	metadata, _ := LoadMetadata()
 	cacheEmpty := len(initial) == 0
-	needsRemoteSync := cacheEmpty || IsRemoteSyncDue(metadata)
-	needsLocalScan := IsLocalScanDue(metadata)
+	// Sync on startup if the index is older than the configured intervals,
+	// an empty index is filled even with automatic sync turned off
+	autoSync := !useDaemon && config.AutoSync()
+	needsRemoteSync := (!useDaemon && cacheEmpty) || (autoSync && IsRemoteSyncDue(metadata, config.GetRemoteSyncInterval()))
+	needsLocalScan := autoSync && IsLocalScanDue(metadata, config.GetLocalScanInterval())
 
 	// If local scan is due, run it inline (fast) before showing UI
 	// This ensures local repos are always up-to-date
 	if needsLocalScan && len(config.GetRepoRoots()) > 0 {
-		if updated, err := runLocalScan(config, initial); err == nil {
+		if updated, err := runSync(context.Background(), config, false, nopReporter{}); err == nil {
 			initial = updated
 		}
 	}
@@ -60,16 +93,28 @@ func main() {
 
 	// If remote sync is due (and not first run - we'll spawn after config is set)
 	// spawn detached background process
-	syncSpawned := false
+	syncPID := 0
 	if needsRemoteSync && !firstRun && !isSyncRunning() {
-		syncSpawned = spawnDetachedSync()
+		syncPID = spawnDetachedSync()
 	}
 
 	go func() {
 		doRefresh := func() {
 			uiMsgs <- refreshStartedMsg{}
+			if useDaemon {
+				err := daemonRefresh(true)
+				if !errors.Is(err, ErrNoDaemon) {
+					if err != nil {
+						uiMsgs <- errorMsg{err: err}
+					} else if repos, err := daemonRepos(); err == nil {
+						uiMsgs <- reposUpdatedMsg(repos)
+					}
+					uiMsgs <- refreshFinishedMsg{}
+					return
+				}
+			}
 			cfg, _ := LoadConfig()
-			progressiveRefresh(cfg, uiMsgs)
+			_, _ = runSync(context.Background(), cfg, true, tuiReporter{uiMsgs})
 		}
 
 		// Manual refresh requests only - auto sync handled by detached process
@@ -78,11 +123,11 @@ func main() {
 		}
 	}()
 
-	selectedRepo, action, selectedPath, updatedConfig := ui(initial, config, uiMsgs, refreshChan, initialMtime, syncSpawned, firstRun)
-	executeAction(selectedRepo, action, selectedPath, updatedConfig)
+	selectedRepo, action, selectedPath, custom, updatedConfig := ui(initial, config, uiMsgs, refreshChan, initialMtime, syncPID, firstRun)
+	executeAction(selectedRepo, action, selectedPath, custom, updatedConfig)
 }
 
-func executeAction(repo *Repository, action Action, selectedPath string, config Config) {
+func executeAction(repo *Repository, action Action, selectedPath string, custom *CustomCommand, config Config) {
 	if action == ActionNone {
 		return
 	}
@@ -92,7 +137,7 @@ func executeAction(repo *Repository, action Action, selectedPath string, config
 		if strings.TrimSpace(selectedPath) == "" {
 			return
 		}
-		if err := OpenInEditor(selectedPath, "manual"); err != nil {
+		if err := OpenInEditor(selectedPath, Repository{Name: "manual"}, config); err != nil {
 			if errors.Is(err, ErrNoEditor) {
 				fmt.Fprintln(os.Stderr, "Error: $EDITOR is not set")
 				os.Exit(1)
@@ -101,7 +146,7 @@ func executeAction(repo *Repository, action Action, selectedPath string, config
 			os.Exit(1)
 		}
 		return
-	case ActionOpen:
+	case ActionOpen, ActionTmuxSession, ActionTmuxWindow:
 		if repo == nil {
 			return
 		}
@@ -111,17 +156,29 @@ func executeAction(repo *Repository, action Action, selectedPath string, config
 			os.Exit(1)
 		}
 
-		if err := OpenInEditor(localPath, repo.Name); err != nil {
+		reportHookError(runHooks(context.Background(), hookPreOpen, *repo, localPath, config))
+
+		strategy := config.GetOpenStrategy()
+		switch action {
+		case ActionTmuxSession:
+			strategy = openTmuxSession
+		case ActionTmuxWindow:
+			strategy = openTmuxWindow
+		}
+
+		if err := OpenRepo(localPath, *repo, config, strategy); err != nil {
 			if errors.Is(err, ErrNoEditor) {
 				fmt.Fprintln(os.Stderr, "Error: $EDITOR is not set")
 				os.Exit(1)
 			}
-			fmt.Fprintln(os.Stderr, "Failed to open editor:", err)
+			fmt.Fprintln(os.Stderr, "Failed to open repo:", err)
 			os.Exit(1)
 		}
 
 		_ = RecordUsage(*repo)
 
+		reportHookError(runHooks(context.Background(), hookPostOpen, *repo, localPath, config))
+
 	case ActionCopy:
 		if repo == nil {
 			return
@@ -158,5 +215,16 @@ func executeAction(repo *Repository, action Action, selectedPath string, config
 		}
 
 		_ = RecordUsage(*repo)
+
+	case ActionCustom:
+		if repo == nil || custom == nil {
+			return
+		}
+		_ = RecordUsage(*repo)
+
+		if err := executeCustomCommand(*custom, *repo, config); err != nil {
+			fmt.Fprintf(os.Stderr, "%s failed: %v\n", custom.Name, err)
+			os.Exit(1)
+		}
 	}
 }
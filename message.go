@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -16,8 +17,45 @@ const (
 )
 
 type StatusMessage struct {
+	ID    int // Sequence number, used to match delayed clear timers
 	Text  string
 	Level MessageLevel
+	Hint  string // Optional suggested action, e.g. "press space r to retry"
+	Time  time.Time
+}
+
+// messageLogSize is the maximum number of messages kept in the history
+const messageLogSize = 200
+
+// messageLog is a bounded, timestamped history of status messages
+type messageLog struct {
+	entries []StatusMessage
+	nextID  int
+}
+
+// add timestamps the message, assigns it an ID and appends it to the log,
+// dropping the oldest entry when the log is full
+func (l *messageLog) add(msg StatusMessage) StatusMessage {
+	l.nextID++
+	msg.ID = l.nextID
+	msg.Time = time.Now()
+
+	l.entries = append(l.entries, msg)
+	if len(l.entries) > messageLogSize {
+		l.entries = l.entries[len(l.entries)-messageLogSize:]
+	}
+	return msg
+}
+
+// errorCount returns the number of error messages in the log
+func (l *messageLog) errorCount() int {
+	n := 0
+	for _, e := range l.entries {
+		if e.Level == ErrorLevel {
+			n++
+		}
+	}
+	return n
 }
 
 var (
@@ -55,8 +93,12 @@ func (m StatusMessage) Render(width int) string {
 		return ""
 	}
 
-	// Format: --- LEVEL: [message] ---
-	content := fmt.Sprintf("--- %s: %s ---", m.levelPrefix(), m.Text)
+	// Format: --- LEVEL: [message] ([hint]) ---
+	text := m.Text
+	if m.Hint != "" {
+		text += " (" + m.Hint + ")"
+	}
+	content := fmt.Sprintf("--- %s: %s ---", m.levelPrefix(), text)
 
 	// Center the message content
 	contentWidth := lipgloss.Width(content)
@@ -205,3 +247,8 @@ func wrapText(text string, maxWidth int) []string {
 func (m StatusMessage) IsEmpty() bool {
 	return m.Text == ""
 }
+
+// IsPinned returns true for errors, which stay visible until acknowledged
+func (m StatusMessage) IsPinned() bool {
+	return m.Level == ErrorLevel && m.Text != ""
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openMessageLog shows the message history scrolled to the newest entry.
+// Viewing the log acknowledges a pinned error.
+func (m *Model) openMessageLog() {
+	m.showLog = true
+	m.logScroll = max(0, len(m.messages.entries)-m.logViewHeight())
+	if m.message.IsPinned() {
+		m.clearMessage()
+	}
+}
+
+// logViewHeight is the number of log entries visible at once
+func (m *Model) logViewHeight() int {
+	height := m.height
+	if height == 0 {
+		height = 24
+	}
+	// Border(2) + title(1) + blank lines(2) + hint(1)
+	return max(3, height-7)
+}
+
+// logLines renders every log entry as one line, oldest first
+func (m *Model) logLines(width int) []string {
+	lines := make([]string, 0, len(m.messages.entries))
+	for _, e := range m.messages.entries {
+		text := e.Text
+		if e.Hint != "" {
+			text += " (" + e.Hint + ")"
+		}
+
+		style := infoMsgStyle
+		switch e.Level {
+		case WarningLevel:
+			style = warningMsgStyle
+		case ErrorLevel:
+			style = errorMsgStyle
+		}
+
+		prefix := fmt.Sprintf("%s %-7s ", e.Time.Format("15:04:05"), e.levelPrefix())
+		lines = append(lines, dimStyle.Render(prefix)+style.Render(padOrTrim(firstLine(text), max(10, width-len(prefix)))))
+	}
+	return lines
+}
+
+func (m Model) updateMessageLog(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	model, cmd, _ := dispatchKey(messageLogKeys(), m, msg)
+	return model, cmd
+}
+
+func (m Model) buildMessageLogBox() string {
+	width := m.width
+	if width == 0 {
+		width = 80
+	}
+	// Leave room for the overlay border and padding
+	innerW := clamp(width-8, 30, 120)
+
+	all := m.logLines(innerW)
+	viewH := m.logViewHeight()
+	scroll := clamp(m.logScroll, 0, max(0, len(all)-viewH))
+	end := min(len(all), scroll+viewH)
+
+	var lines []string
+	lines = append(lines, inputTextStyle.Render("Messages"))
+	lines = append(lines, "")
+
+	if len(all) == 0 {
+		lines = append(lines, dimStyle.Render("no messages yet"))
+	} else {
+		lines = append(lines, all[scroll:end]...)
+	}
+
+	lines = append(lines, "")
+	hints := keybindStyle.Render("↑↓ scroll  esc close")
+	if errors := m.messages.errorCount(); errors > 0 {
+		hints += dimStyle.Render(fmt.Sprintf("  %d errors", errors))
+	}
+	if len(all) > viewH {
+		hints += dimStyle.Render(fmt.Sprintf("  (%d-%d of %d)", scroll+1, end, len(all)))
+	}
+	lines = append(lines, hints)
+
+	return overlayStyle.Render(strings.Join(lines, "\n"))
+}
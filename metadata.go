@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/wealthystudent/fuzzyrepo/index"
 )
 
 // CacheMetadata tracks sync timestamps and state
@@ -12,12 +14,20 @@ type CacheMetadata struct {
 	LastRemoteSync time.Time `json:"last_remote_sync"`
 	LastLocalScan  time.Time `json:"last_local_scan"`
 	RemoteSyncPID  int       `json:"remote_sync_pid,omitempty"` // PID of running sync process (0 if none)
+	SortMode       string    `json:"sort_mode,omitempty"`       // Last used result sort mode
+
+	// Outcome of the last sync, see recordSync
+	LastError        string         `json:"last_error,omitempty"`         // Why it failed, empty if it succeeded
+	LastErrorAt      time.Time      `json:"last_error_at,omitzero"`       // When it failed
+	LastErrorRemote  bool           `json:"last_error_remote,omitempty"`  // It was a remote sync, not a local scan
+	LastSyncDuration time.Duration  `json:"last_sync_duration,omitempty"` // How long it took, in nanoseconds
+	RepoCounts       map[string]int `json:"repo_counts,omitempty"`        // Repos per source ("local", "github") at its last sync
 }
 
-// Sync frequency constants (not configurable by user)
+// Sync intervals used when the config doesn't set them, see SyncConfig
 const (
-	RemoteSyncInterval = 7 * 24 * time.Hour // Weekly
-	LocalScanInterval  = 24 * time.Hour     // Daily
+	DefaultRemoteSyncInterval = 7 * 24 * time.Hour // Weekly
+	DefaultLocalScanInterval  = 24 * time.Hour     // Daily
 )
 
 func getMetadataPath() string {
@@ -43,8 +53,28 @@ func LoadMetadata() (CacheMetadata, error) {
 	return meta, nil
 }
 
-// SaveMetadata saves the cache metadata to disk atomically
-func SaveMetadata(meta CacheMetadata) error {
+// UpdateMetadata changes the saved metadata with update, holding the
+// metadata lock from loading to saving
+func UpdateMetadata(update func(*CacheMetadata)) error {
+	return withMetadataLock(func() error {
+		// Corrupt metadata is started over, like a missing file
+		meta, _ := LoadMetadata()
+		update(&meta)
+		return writeMetadata(meta)
+	})
+}
+
+// withMetadataLock runs fn holding the lock guarding metadata.json
+func withMetadataLock(fn func() error) error {
+	lock, err := index.LockFile(getMetadataPath() + ".lock")
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	return fn()
+}
+
+func writeMetadata(meta CacheMetadata) error {
 	path := getMetadataPath()
 	dir := filepath.Dir(path)
 
@@ -65,21 +95,21 @@ func SaveMetadata(meta CacheMetadata) error {
 }
 
 // IsRemoteSyncDue returns true if a remote sync should be triggered
-// (more than 7 days since last sync, or never synced)
-func IsRemoteSyncDue(meta CacheMetadata) bool {
+// (the last sync is older than interval, or never synced)
+func IsRemoteSyncDue(meta CacheMetadata, interval time.Duration) bool {
 	if meta.LastRemoteSync.IsZero() {
 		return true
 	}
-	return time.Since(meta.LastRemoteSync) > RemoteSyncInterval
+	return time.Since(meta.LastRemoteSync) > interval
 }
 
 // IsLocalScanDue returns true if a local scan should be triggered
-// (more than 1 day since last scan, or never scanned)
-func IsLocalScanDue(meta CacheMetadata) bool {
+// (the last scan is older than interval, or never scanned)
+func IsLocalScanDue(meta CacheMetadata, interval time.Duration) bool {
 	if meta.LastLocalScan.IsZero() {
 		return true
 	}
-	return time.Since(meta.LastLocalScan) > LocalScanInterval
+	return time.Since(meta.LastLocalScan) > interval
 }
 
 // UpdateRemoteSyncTime updates the last remote sync timestamp
@@ -92,6 +122,36 @@ func (m *CacheMetadata) UpdateLocalScanTime() {
 	m.LastLocalScan = time.Now()
 }
 
+// recordSync stores the outcome of a sync that took took. A successful sync
+// updates the timestamps of what it synced and the repo count of each
+// source in counts. It clears the last error, unless a local scan succeeds
+// after a remote sync failed: the GitHub repos are still stale then.
+func (m *CacheMetadata) recordSync(remote bool, took time.Duration, counts map[string]int, err error) {
+	m.LastSyncDuration = took
+	if err != nil {
+		m.LastError = err.Error()
+		m.LastErrorAt = time.Now()
+		m.LastErrorRemote = remote
+		return
+	}
+
+	if remote || !m.LastErrorRemote {
+		m.LastError = ""
+		m.LastErrorAt = time.Time{}
+		m.LastErrorRemote = false
+	}
+	m.UpdateLocalScanTime()
+	if remote {
+		m.UpdateRemoteSyncTime()
+	}
+	if m.RepoCounts == nil {
+		m.RepoCounts = make(map[string]int)
+	}
+	for source, n := range counts {
+		m.RepoCounts[source] = n
+	}
+}
+
 // GetCacheMtime returns the modification time of the cache file
 // Returns zero time if file doesn't exist or error occurs
 func GetCacheMtime() time.Time {
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// A minimal MessagePack codec, covering the types the Neovim API uses.
+// See https://github.com/msgpack/msgpack/blob/master/spec.md
+
+// msgpackExt is an extension value; Neovim sends buffer, window and
+// tabpage handles as extensions
+type msgpackExt struct {
+	Type int8
+	Data []byte
+}
+
+// msgpackEncode appends the encoding of v to b. Supported types are nil,
+// bool, integers, float64, string, []byte, []any, []string and map[string]any.
+func msgpackEncode(b []byte, v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0), nil
+	case bool:
+		if v {
+			return append(b, 0xc3), nil
+		}
+		return append(b, 0xc2), nil
+	case int:
+		return msgpackEncodeInt(b, int64(v)), nil
+	case int64:
+		return msgpackEncodeInt(b, v), nil
+	case uint32:
+		return msgpackEncodeInt(b, int64(v)), nil
+	case float64:
+		b = append(b, 0xcb)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(v)), nil
+	case string:
+		return msgpackEncodeString(b, v), nil
+	case []byte:
+		return msgpackEncodeBin(b, v), nil
+	case []string:
+		b = msgpackEncodeLen(b, len(v), 0x90, 0xdc, 0xdd)
+		for _, s := range v {
+			b = msgpackEncodeString(b, s)
+		}
+		return b, nil
+	case []any:
+		b = msgpackEncodeLen(b, len(v), 0x90, 0xdc, 0xdd)
+		for _, e := range v {
+			var err error
+			if b, err = msgpackEncode(b, e); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case map[string]any:
+		b = msgpackEncodeLen(b, len(v), 0x80, 0xde, 0xdf)
+		// Sorted keys keep the encoding deterministic
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b = msgpackEncodeString(b, k)
+			var err error
+			if b, err = msgpackEncode(b, v[k]); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("msgpack: cannot encode %T", v)
+}
+
+func msgpackEncodeInt(b []byte, v int64) []byte {
+	switch {
+	case v >= 0 && v <= math.MaxInt8:
+		return append(b, byte(v))
+	case v < 0 && v >= -32:
+		return append(b, byte(v))
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		return append(b, 0xd0, byte(v))
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(append(b, 0xd1), uint16(v))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(v))
+}
+
+// msgpackEncodeLen appends an array or map header using the fix, 16 and 32
+// bit forms
+func msgpackEncodeLen(b []byte, n int, fix, code16, code32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, code16), uint16(n))
+	}
+	return binary.BigEndian.AppendUint32(append(b, code32), uint32(n))
+}
+
+func msgpackEncodeString(b []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		b = append(b, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		b = append(b, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+	}
+	return append(b, s...)
+}
+
+func msgpackEncodeBin(b []byte, data []byte) []byte {
+	n := len(data)
+	switch {
+	case n <= math.MaxUint8:
+		b = append(b, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		b = binary.BigEndian.AppendUint16(append(b, 0xc5), uint16(n))
+	default:
+		b = binary.BigEndian.AppendUint32(append(b, 0xc6), uint32(n))
+	}
+	return append(b, data...)
+}
+
+// msgpackDecode reads one value. Integers decode to int64 (uint64 above
+// MaxInt64), strings to string, binary to []byte, arrays to []any, maps to
+// map[any]any and extensions to msgpackExt.
+func msgpackDecode(r *bufio.Reader) (any, error) {
+	code, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case code <= 0x7f:
+		return int64(code), nil
+	case code >= 0xe0:
+		return int64(int8(code)), nil
+	case code&0xe0 == 0xa0:
+		return msgpackReadString(r, int(code&0x1f))
+	case code&0xf0 == 0x90:
+		return msgpackReadArray(r, int(code&0x0f))
+	case code&0xf0 == 0x80:
+		return msgpackReadMap(r, int(code&0x0f))
+	}
+
+	switch code {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err := msgpackReadUint(r, 1<<(code-0xcc))
+		if err != nil {
+			return nil, err
+		}
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case 0xd0:
+		n, err := msgpackReadUint(r, 1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := msgpackReadUint(r, 2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := msgpackReadUint(r, 4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := msgpackReadUint(r, 8)
+		return int64(n), err
+	case 0xca:
+		n, err := msgpackReadUint(r, 4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := msgpackReadUint(r, 8)
+		return math.Float64frombits(n), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := msgpackReadUint(r, 1<<(code-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadString(r, int(n))
+	case 0xc4, 0xc5, 0xc6:
+		n, err := msgpackReadUint(r, 1<<(code-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadBytes(r, int(n))
+	case 0xdc, 0xdd:
+		n, err := msgpackReadUint(r, 2<<(code-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadArray(r, int(n))
+	case 0xde, 0xdf:
+		n, err := msgpackReadUint(r, 2<<(code-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadMap(r, int(n))
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return msgpackReadExt(r, 1<<(code-0xd4))
+	case 0xc7, 0xc8, 0xc9:
+		n, err := msgpackReadUint(r, 1<<(code-0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return msgpackReadExt(r, int(n))
+	}
+
+	return nil, fmt.Errorf("msgpack: unknown type 0x%02x", code)
+}
+
+// msgpackReadUint reads a big endian unsigned integer of size bytes
+func msgpackReadUint(r *bufio.Reader, size int) (uint64, error) {
+	buf, err := msgpackReadBytes(r, size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range buf {
+		n = n<<8 | uint64(c)
+	}
+	return n, nil
+}
+
+func msgpackReadBytes(r *bufio.Reader, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func msgpackReadString(r *bufio.Reader, n int) (string, error) {
+	buf, err := msgpackReadBytes(r, n)
+	return string(buf), err
+}
+
+func msgpackReadArray(r *bufio.Reader, n int) ([]any, error) {
+	arr := make([]any, n)
+	for i := range arr {
+		v, err := msgpackDecode(r)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = v
+	}
+	return arr, nil
+}
+
+func msgpackReadMap(r *bufio.Reader, n int) (map[any]any, error) {
+	m := make(map[any]any, n)
+	for range n {
+		k, err := msgpackDecode(r)
+		if err != nil {
+			return nil, err
+		}
+		v, err := msgpackDecode(r)
+		if err != nil {
+			return nil, err
+		}
+		// Keys of unhashable types can't be stored, and Neovim never sends them
+		switch k.(type) {
+		case []any, map[any]any, []byte, msgpackExt:
+			continue
+		}
+		m[k] = v
+	}
+	return m, nil
+}
+
+func msgpackReadExt(r *bufio.Reader, n int) (msgpackExt, error) {
+	t, err := r.ReadByte()
+	if err != nil {
+		return msgpackExt{}, err
+	}
+	data, err := msgpackReadBytes(r, n)
+	return msgpackExt{Type: int8(t), Data: data}, err
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func decodeAll(t *testing.T, data []byte) any {
+	t.Helper()
+	r := bufio.NewReader(bytes.NewReader(data))
+	v, err := msgpackDecode(r)
+	if err != nil {
+		t.Fatalf("decode % x: %v", data, err)
+	}
+	if _, err := r.ReadByte(); err == nil {
+		t.Fatalf("decode % x: trailing bytes", data)
+	}
+	return v
+}
+
+// Every type msgpackEncode handles must decode back to its value, in the
+// decoded form: integers as int64, arrays as []any, maps as map[any]any
+func TestMsgpackRoundTrip(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	huge := strings.Repeat("y", 70000)
+	many := make([]any, 20)
+	manyWant := make([]any, 20)
+	bigMap := make(map[string]any)
+	bigMapWant := make(map[any]any)
+	for i := range many {
+		many[i] = i
+		manyWant[i] = int64(i)
+		key := string(rune('a' + i))
+		bigMap[key] = i
+		bigMapWant[key] = int64(i)
+	}
+
+	tests := []struct {
+		name string
+		in   any
+		want any
+	}{
+		{"nil", nil, nil},
+		{"true", true, true},
+		{"false", false, false},
+		{"positive fixint", 5, int64(5)},
+		{"negative fixint", -32, int64(-32)},
+		{"int8", -100, int64(-100)},
+		{"int16", 1000, int64(1000)},
+		{"negative int16", -1000, int64(-1000)},
+		{"int32", 100000, int64(100000)},
+		{"int64", int64(math.MaxInt64), int64(math.MaxInt64)},
+		{"min int64", int64(math.MinInt64), int64(math.MinInt64)},
+		{"uint32", uint32(math.MaxUint32), int64(math.MaxUint32)},
+		{"float64", 3.25, 3.25},
+		{"fixstr", "hello", "hello"},
+		{"empty string", "", ""},
+		{"str8", long[:40], long[:40]},
+		{"str16", long, long},
+		{"str32", huge, huge},
+		{"bin8", []byte{1, 2, 3}, []byte{1, 2, 3}},
+		{"bin16", []byte(long), []byte(long)},
+		{"bin32", []byte(huge), []byte(huge)},
+		{"string array", []string{"a", "b"}, []any{"a", "b"}},
+		{"fixarray", []any{1, "two", nil, true}, []any{int64(1), "two", nil, true}},
+		{"array16", many, manyWant},
+		{"fixmap", map[string]any{"k": "v", "n": -1}, map[any]any{"k": "v", "n": int64(-1)}},
+		{"map16", bigMap, bigMapWant},
+		{"nested", []any{map[string]any{"list": []string{"x"}}}, []any{map[any]any{"list": []any{"x"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := msgpackEncode(nil, tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := decodeAll(t, data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpackEncodeUnsupported(t *testing.T) {
+	if _, err := msgpackEncode(nil, struct{}{}); err == nil {
+		t.Error("encoding a struct succeeded")
+	}
+	if _, err := msgpackEncode(nil, []any{1, struct{}{}}); err == nil {
+		t.Error("encoding a struct in an array succeeded")
+	}
+}
+
+// Types Neovim may send that msgpackEncode never writes
+func TestMsgpackDecodeForeignTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want any
+	}{
+		{"uint8", []byte{0xcc, 0xff}, int64(255)},
+		{"uint16", []byte{0xcd, 0xff, 0xff}, int64(65535)},
+		{"uint32", []byte{0xce, 0xff, 0xff, 0xff, 0xff}, int64(math.MaxUint32)},
+		{"uint64", []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, uint64(math.MaxUint64)},
+		{"float32", []byte{0xca, 0x3f, 0xc0, 0x00, 0x00}, 1.5},
+		{"fixext1", []byte{0xd4, 0x01, 0x07}, msgpackExt{Type: 1, Data: []byte{7}}},
+		{"fixext4", []byte{0xd6, 0x02, 1, 2, 3, 4}, msgpackExt{Type: 2, Data: []byte{1, 2, 3, 4}}},
+		{"ext8", []byte{0xc7, 0x03, 0x00, 9, 8, 7}, msgpackExt{Type: 0, Data: []byte{9, 8, 7}}},
+		{"map with array key", []byte{0x82, 0x91, 0x01, 0x02, 0xa1, 'k', 0x03}, map[any]any{"k": int64(3)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeAll(t, tt.data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgpackDecodeErrors(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"unknown type":     {0xc1},
+		"truncated string": {0xa5, 'a'},
+		"truncated array":  {0x92, 0x01},
+		"empty":            {},
+	} {
+		if _, err := msgpackDecode(bufio.NewReader(bytes.NewReader(data))); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// nvimRPCTimeout bounds connecting to Neovim and waiting for a reply
+const nvimRPCTimeout = 5 * time.Second
+
+// msgpack-RPC message types
+const (
+	rpcRequest      = 0
+	rpcResponse     = 1
+	rpcNotification = 2
+)
+
+var ErrNeovimRPC = errors.New("neovim rpc failed")
+
+// nvimClient is a msgpack-RPC connection to a running Neovim, e.g. the one
+// in $NVIM when fuzzyrepo runs inside its :terminal
+type nvimClient struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	nextID uint32
+}
+
+// dialNvim connects to a Neovim server address: a unix socket path (or
+// named pipe) or a host:port TCP address
+func dialNvim(addr string) (*nvimClient, error) {
+	network := "unix"
+	if !strings.ContainsAny(addr, `/\`) && strings.Contains(addr, ":") {
+		network = "tcp"
+	}
+
+	conn, err := net.DialTimeout(network, addr, nvimRPCTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("%w: connect to %s: %v", ErrNeovimRPC, addr, err)
+	}
+	return &nvimClient{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (c *nvimClient) Close() error {
+	return c.conn.Close()
+}
+
+// call invokes an API method and waits for its result. Notifications and
+// requests Neovim sends in the meantime are skipped.
+func (c *nvimClient) call(method string, args ...any) (any, error) {
+	c.nextID++
+	id := c.nextID
+
+	if args == nil {
+		args = []any{}
+	}
+	msg, err := msgpackEncode(nil, []any{rpcRequest, id, method, args})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNeovimRPC, method, err)
+	}
+
+	if err := c.conn.SetDeadline(time.Now().Add(nvimRPCTimeout)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNeovimRPC, err)
+	}
+	if _, err := c.conn.Write(msg); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNeovimRPC, method, err)
+	}
+
+	for {
+		v, err := msgpackDecode(c.r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrNeovimRPC, method, err)
+		}
+		resp, ok := v.([]any)
+		if !ok || len(resp) != 4 || resp[0] != int64(rpcResponse) || resp[1] != int64(id) {
+			continue
+		}
+		if resp[2] != nil {
+			return nil, fmt.Errorf("%w: %s: %s", ErrNeovimRPC, method, nvimErrorText(resp[2]))
+		}
+		return resp[3], nil
+	}
+}
+
+// nvimErrorText extracts the message from a Neovim error, which is sent as
+// [error type, message]
+func nvimErrorText(v any) string {
+	if e, ok := v.([]any); ok && len(e) == 2 {
+		if s, ok := e[1].(string); ok {
+			return s
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// execLua runs Lua code in Neovim; args are available to it as "..."
+func (c *nvimClient) execLua(code string, args ...any) (any, error) {
+	if args == nil {
+		args = []any{}
+	}
+	return c.call("nvim_exec_lua", code, args)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeNvim serves msgpack-RPC on a unix socket like Neovim does, answering
+// every request with handle. Before each response it sends a notification,
+// which clients must skip.
+func fakeNvim(t *testing.T, handle func(method string, args []any) (rpcErr, result any)) string {
+	t.Helper()
+	addr := filepath.Join(t.TempDir(), "nvim.sock")
+	ln, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					v, err := msgpackDecode(r)
+					if err != nil {
+						return
+					}
+					req, ok := v.([]any)
+					if !ok || len(req) != 4 || req[0] != int64(rpcRequest) {
+						t.Errorf("not a request: %#v", v)
+						return
+					}
+					method, _ := req[2].(string)
+					args, _ := req[3].([]any)
+					rpcErr, result := handle(method, args)
+
+					note, _ := msgpackEncode(nil, []any{rpcNotification, "nvim_buf_lines_event", []any{}})
+					resp, err := msgpackEncode(nil, []any{rpcResponse, req[1], rpcErr, result})
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					if _, err := conn.Write(append(note, resp...)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return addr
+}
+
+func TestNvimExecLua(t *testing.T) {
+	var gotMethod string
+	var gotArgs []any
+	addr := fakeNvim(t, func(method string, args []any) (any, any) {
+		gotMethod, gotArgs = method, args
+		return nil, map[string]any{"ok": true, "tab": 3}
+	})
+
+	client, err := dialNvim(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	code := "return require('fuzzyrepo').open_repo(...)"
+	result, err := client.execLua(code, "/src/acme/api", map[string]any{"strategy": "tab"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if gotMethod != "nvim_exec_lua" {
+		t.Errorf("method = %q, want nvim_exec_lua", gotMethod)
+	}
+	wantArgs := []any{code, []any{"/src/acme/api", map[any]any{"strategy": "tab"}}}
+	if !reflect.DeepEqual(gotArgs, wantArgs) {
+		t.Errorf("args = %#v, want %#v", gotArgs, wantArgs)
+	}
+	wantResult := map[any]any{"ok": true, "tab": int64(3)}
+	if !reflect.DeepEqual(result, wantResult) {
+		t.Errorf("result = %#v, want %#v", result, wantResult)
+	}
+
+	// Without arguments Neovim still expects an (empty) argument array
+	if _, err := client.execLua("return 1"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotArgs, []any{"return 1", []any{}}) {
+		t.Errorf("args = %#v, want an empty argument array", gotArgs)
+	}
+}
+
+func TestNvimCallError(t *testing.T) {
+	addr := fakeNvim(t, func(method string, args []any) (any, any) {
+		// Neovim sends errors as [error type, message]
+		return []any{1, "E5108: module 'fuzzyrepo' not found"}, nil
+	})
+
+	client, err := dialNvim(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	_, err = client.execLua("return require('fuzzyrepo')")
+	if !errors.Is(err, ErrNeovimRPC) {
+		t.Fatalf("err = %v, want ErrNeovimRPC", err)
+	}
+	if !strings.Contains(err.Error(), "module 'fuzzyrepo' not found") {
+		t.Errorf("err = %v, want the Neovim error message", err)
+	}
+}
+
+func TestDialNvimFails(t *testing.T) {
+	_, err := dialNvim(filepath.Join(t.TempDir(), "missing.sock"))
+	if !errors.Is(err, ErrNeovimRPC) {
+		t.Errorf("err = %v, want ErrNeovimRPC", err)
+	}
+}
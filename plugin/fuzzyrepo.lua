@@ -1,3 +1,12 @@
 vim.api.nvim_create_user_command("Fuzzyrepo", function()
   require("fuzzyrepo").open()
 end, {})
+
+vim.api.nvim_create_user_command("FuzzyrepoPick", function(opts)
+  require("fuzzyrepo").pick({ backend = opts.args ~= "" and opts.args or nil })
+end, {
+  nargs = "?",
+  complete = function()
+    return { "auto", "telescope", "fzf-lua", "snacks" }
+  end,
+})
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/wealthystudent/fuzzyrepo/index"
+)
+
+// searchRepos filters repos by the fuzzy query and orders them by mode.
+// The list is drawn bottom-up, so the best result is the last element.
+func searchRepos(repos []Repository, query string, usage UsageData, mode index.SortMode) []Repository {
+	ranked := index.Rank(repos, query, usage, mode)
+	// Without a query, frecency keeps the usage order
+	if mode == index.SortFrecency && strings.TrimSpace(query) == "" {
+		return ranked
+	}
+	slices.Reverse(ranked)
+	return ranked
+}
@@ -1,139 +1,30 @@
 package main
 
 import (
-	"bufio"
-	"io/fs"
-	"os"
-	"path/filepath"
-	"regexp"
-	"strings"
+	"github.com/wealthystudent/fuzzyrepo/index"
 )
 
-type Repository struct {
-	Owner       string `json:"owner"`
-	Name        string `json:"name"`
-	FullName    string `json:"full_name"`
-	SSHURL      string `json:"ssh_url"`
-	LocalPath   string `json:"local_path"`
-	ExistsLocal bool   `json:"exists_local"`
-	Affiliation string `json:"affiliation"` // "owner", "collaborator", "organization_member", "local"
-	SearchText  string `json:"-"`
-}
+// Repository is a repo of the index, see the index package
+type Repository = index.Repository
 
-func (r *Repository) ComputeSearchText() {
-	r.SearchText = strings.ToLower(r.Owner + " " + r.Name + " " + r.FullName)
+// RepoFilter selects which cached repos are displayed.
+// It starts out from the config and can be toggled for a single session.
+type RepoFilter struct {
+	ShowOwner        bool
+	ShowCollaborator bool
+	ShowOrgMember    bool
+	ShowLocal        bool
+	LocalClonesOnly  bool // Only repos that have a local clone
+	HideArchived     bool // Hide repos archived on GitHub
 }
 
-func extractOriginURL(gitConfigPath string) (string, error) {
-	file, err := os.Open(gitConfigPath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	inOriginSection := false
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-
-		if strings.HasPrefix(line, "[remote \"origin\"]") {
-			inOriginSection = true
-			continue
-		}
-
-		if inOriginSection {
-			if strings.HasPrefix(line, "[") {
-				break
-			}
-			if strings.HasPrefix(line, "url") {
-				parts := strings.SplitN(line, "=", 2)
-				if len(parts) == 2 {
-					return strings.TrimSpace(parts[1]), nil
-				}
-			}
-		}
-	}
-
-	return "", nil
-}
-
-var (
-	sshURLPattern   = regexp.MustCompile(`git@github\.com:([^/]+)/(.+?)(?:\.git)?$`)
-	httpsURLPattern = regexp.MustCompile(`https://github\.com/([^/]+)/(.+?)(?:\.git)?$`)
-)
-
-func parseGitHubURL(url string) (owner, name string, ok bool) {
-	if matches := sshURLPattern.FindStringSubmatch(url); matches != nil {
-		return matches[1], strings.TrimSuffix(matches[2], ".git"), true
-	}
-	if matches := httpsURLPattern.FindStringSubmatch(url); matches != nil {
-		return matches[1], strings.TrimSuffix(matches[2], ".git"), true
-	}
-	return "", "", false
-}
-
-func indexLocalRepos(roots []string) []Repository {
-	var repos []Repository
-
-	for _, root := range roots {
-		if root == "" {
-			continue
-		}
-
-		filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return nil
-			}
-
-			if d.IsDir() && d.Name() == ".git" {
-				repoPath := filepath.Dir(path)
-				repo := buildRepoFromLocalPath(repoPath)
-				repos = append(repos, repo)
-				return fs.SkipDir
-			}
-
-			if d.IsDir() && (d.Name() == "node_modules" || d.Name() == "vendor" || d.Name() == ".cache") {
-				return fs.SkipDir
-			}
-
-			return nil
-		})
-	}
-
-	return repos
-}
-
-func buildRepoFromLocalPath(repoPath string) Repository {
-	gitConfigPath := filepath.Join(repoPath, ".git", "config")
-	originURL, _ := extractOriginURL(gitConfigPath)
-
-	owner, name, ok := parseGitHubURL(originURL)
-	if !ok {
-		name = filepath.Base(repoPath)
-		owner = "local"
-	}
-
-	repo := Repository{
-		Owner:       owner,
-		Name:        name,
-		FullName:    owner + "/" + name,
-		SSHURL:      originURL,
-		LocalPath:   repoPath,
-		ExistsLocal: true,
-		Affiliation: "local",
-	}
-	repo.ComputeSearchText()
-
-	return repo
-}
-
-// filterRepos filters the full repository cache based on config settings
+// filterRepos filters the full repository cache based on the filter settings
 // Returns a new slice containing only repos that match the filter criteria
-func filterRepos(repos []Repository, cfg Config) []Repository {
+func filterRepos(repos []Repository, filter RepoFilter) []Repository {
 	filtered := make([]Repository, 0, len(repos))
 
 	for _, repo := range repos {
-		if shouldIncludeRepo(repo, cfg) {
+		if shouldIncludeRepo(repo, filter) {
 			filtered = append(filtered, repo)
 		}
 	}
@@ -141,17 +32,24 @@ func filterRepos(repos []Repository, cfg Config) []Repository {
 	return filtered
 }
 
-// shouldIncludeRepo checks if a repo should be included based on config filters
-func shouldIncludeRepo(repo Repository, cfg Config) bool {
+// shouldIncludeRepo checks if a repo should be included based on the filters
+func shouldIncludeRepo(repo Repository, filter RepoFilter) bool {
+	if filter.LocalClonesOnly && !repo.ExistsLocal {
+		return false
+	}
+	if filter.HideArchived && repo.Archived {
+		return false
+	}
+
 	switch repo.Affiliation {
 	case "owner", "": // Empty affiliation treated as owner (backwards compatibility)
-		return cfg.ShowOwner
+		return filter.ShowOwner
 	case "collaborator":
-		return cfg.ShowCollaborator
+		return filter.ShowCollaborator
 	case "organization_member":
-		return cfg.ShowOrgMember
+		return filter.ShowOrgMember
 	case "local":
-		return cfg.ShowLocal
+		return filter.ShowLocal
 	default:
 		// Unknown affiliation, include by default
 		return true
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/wealthystudent/fuzzyrepo/index"
+)
+
+const (
+	ruleFieldPattern = iota
+	ruleFieldPath
+	ruleFieldCount
+)
+
+// ruleEditor is the clone rule list editor, with a live tester for one repo
+type ruleEditor struct {
+	rules  []CloneRule // Working copy, written to the config on save
+	cursor int
+	dirty  bool
+
+	editing bool // Editing the rule under the cursor
+	adding  bool // The edited rule was just added, drop it on cancel
+	field   int
+	inputs  [ruleFieldCount]textinput.Model
+
+	testing bool // Test input is focused
+	test    textinput.Model
+}
+
+// newRuleInput creates a text input styled like the config overlay inputs
+func newRuleInput(placeholder string, width int) textinput.Model {
+	ti := textinput.New()
+	ti.CharLimit = 500
+	ti.Width = width
+	ti.Prompt = ""
+	ti.Placeholder = placeholder
+	ti.TextStyle = lipgloss.NewStyle().Background(bgColor).Foreground(lipgloss.Color("#ffffff"))
+	ti.PlaceholderStyle = lipgloss.NewStyle().Background(bgColor).Foreground(lipgloss.Color("#444444"))
+	ti.PromptStyle = lipgloss.NewStyle().Background(bgColor)
+	ti.Cursor.Style = lipgloss.NewStyle().Background(bgColor)
+	ti.Cursor.TextStyle = lipgloss.NewStyle().Background(bgColor)
+	return ti
+}
+
+// openRuleEditor opens the clone rule editor, testing the repo under the cursor
+func (m *Model) openRuleEditor() {
+	e := &ruleEditor{
+		rules: slices.Clone(m.config.CloneRules),
+		test:  newRuleInput("owner/repo", 40),
+	}
+	e.inputs[ruleFieldPattern] = newRuleInput(`^org/.*`, 50)
+	e.inputs[ruleFieldPath] = newRuleInput("/path/to/dir or /src/{owner}/{repo}", 50)
+	if len(m.results) > 0 {
+		e.test.SetValue(m.results[m.cursor].FullName)
+	}
+	m.rules = e
+}
+
+// candidate returns the rule under the cursor with the pattern and path of
+// the edit inputs. Settings only editable in the config file are kept.
+func (e *ruleEditor) candidate() CloneRule {
+	var rule CloneRule
+	if e.cursor < len(e.rules) {
+		rule = e.rules[e.cursor]
+	}
+	// A new index rule, so a pattern compiled for the old one isn't kept
+	rule.CloneRule = index.CloneRule{
+		Pattern: strings.TrimSpace(e.inputs[ruleFieldPattern].Value()),
+		Path:    strings.TrimSpace(e.inputs[ruleFieldPath].Value()),
+		Clone:   rule.Clone,
+	}
+	return rule
+}
+
+// workingRules returns the rules with the one being edited replaced by the
+// current input, so the tester reflects changes as they are typed
+func (e *ruleEditor) workingRules() []CloneRule {
+	if !e.editing {
+		return e.rules
+	}
+	rules := slices.Clone(e.rules)
+	rules[e.cursor] = e.candidate()
+	return rules
+}
+
+func (e *ruleEditor) startEdit(adding bool) {
+	rule := e.rules[e.cursor]
+	e.editing = true
+	e.adding = adding
+	e.field = ruleFieldPattern
+	e.inputs[ruleFieldPattern].SetValue(rule.Pattern)
+	e.inputs[ruleFieldPath].SetValue(rule.Path)
+	e.focusField()
+}
+
+func (e *ruleEditor) focusField() {
+	for i := range e.inputs {
+		e.inputs[i].Blur()
+	}
+	e.inputs[e.field].Focus()
+}
+
+func (e *ruleEditor) stopEdit() {
+	e.editing = false
+	e.adding = false
+	for i := range e.inputs {
+		e.inputs[i].Blur()
+	}
+}
+
+// move swaps the rule under the cursor with its neighbour in direction delta
+func (e *ruleEditor) move(delta int) {
+	to := e.cursor + delta
+	if to < 0 || to >= len(e.rules) {
+		return
+	}
+	e.rules[e.cursor], e.rules[to] = e.rules[to], e.rules[e.cursor]
+	e.cursor = to
+	e.dirty = true
+}
+
+// saveCloneRules validates the edited rules and writes them to the config file
+func (m *Model) saveCloneRules() error {
+	cfg := m.config
+	cfg.CloneRules = slices.Clone(m.rules.rules)
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.compileRules(); err != nil {
+		return err
+	}
+	if err := SaveConfig(cfg); err != nil {
+		return err
+	}
+	m.config = cfg
+	return nil
+}
+
+func (m Model) updateRuleEditor(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	e := m.rules
+
+	switch {
+	case e.editing:
+		if model, cmd, ok := dispatchKey(ruleEditKeys(), m, msg); ok {
+			return model, cmd
+		}
+		var cmd tea.Cmd
+		e.inputs[e.field], cmd = e.inputs[e.field].Update(msg)
+		return m, cmd
+
+	case e.testing:
+		if model, cmd, ok := dispatchKey(ruleTestKeys(), m, msg); ok {
+			return model, cmd
+		}
+		var cmd tea.Cmd
+		e.test, cmd = e.test.Update(msg)
+		return m, cmd
+	}
+
+	model, cmd, _ := dispatchKey(ruleListKeys(), m, msg)
+	return model, cmd
+}
+
+func (m Model) deleteRule() (tea.Model, tea.Cmd) {
+	e := m.rules
+	if len(e.rules) == 0 {
+		return m, nil
+	}
+	e.rules = slices.Delete(e.rules, e.cursor, e.cursor+1)
+	e.cursor = clamp(e.cursor, 0, max(0, len(e.rules)-1))
+	e.dirty = true
+	return m, nil
+}
+
+func (m Model) buildRuleEditorBox() string {
+	e := m.rules
+	width := m.width
+	if width == 0 {
+		width = 80
+	}
+	innerW := clamp(width-8, 40, 100)
+	// Cursor(2) + number(3) + marker(2) + gap(1)
+	patternW := (innerW - 8) * 2 / 5
+	pathW := innerW - 8 - patternW
+
+	rowStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#666666")).
+		Background(bgColor)
+
+	selectedRowStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("#ffffff")).
+		Background(bgColor)
+
+	rules := e.workingRules()
+	cfg := m.config
+	cfg.CloneRules = rules
+
+	testName := strings.TrimSpace(e.test.Value())
+	var test ruleTest
+	if validFullName(testName) {
+		test = cfg.testCloneRules(testName)
+	}
+	winner, hasWinner := test.Winner()
+
+	title := "Clone rules"
+	if e.dirty {
+		title += " (unsaved)"
+	}
+
+	var lines []string
+	lines = append(lines, inputTextStyle.Render(title))
+	if !m.config.UseCloneRules {
+		lines = append(lines, dimStyle.Render("use_clone_rules is off, rules are not applied"))
+	}
+	lines = append(lines, "")
+
+	if len(rules) == 0 {
+		lines = append(lines, dimStyle.Render("no rules yet, press a to add one"))
+	}
+	for i, rule := range rules {
+		marker := dimStyle.Render("  ")
+		switch {
+		case slices.Contains(test.Invalid, i):
+			marker = statusErrorStyle.Render("! ")
+		case hasWinner && winner.Index == i:
+			marker = localYesStyle.Render("✓ ")
+		case slices.ContainsFunc(test.Matches, func(r ruleMatch) bool { return r.Index == i }):
+			marker = dimStyle.Render("· ")
+		}
+
+		style, cursor := rowStyle, "  "
+		if i == e.cursor {
+			style, cursor = selectedRowStyle, "▸ "
+		}
+		lines = append(lines, style.Render(cursor+fmt.Sprintf("%2d ", i+1))+marker+
+			style.Render(padOrTrim(rule.Pattern, patternW)+" "+padOrTrim(rule.Path, pathW)))
+	}
+
+	lines = append(lines, "")
+	lines = append(lines, configLabelStyle.Render(fmt.Sprintf("%-10s", "Test"))+e.test.View())
+	switch {
+	case !validFullName(testName):
+		lines = append(lines, dimStyle.Render("enter owner/repo to see which rule applies"))
+	case hasWinner:
+		lines = append(lines, localYesStyle.Render("→ "+test.Path)+dimStyle.Render(fmt.Sprintf("  rule %d", winner.Index+1)))
+	case len(test.Matches) > 0:
+		lines = append(lines, repoNameStyle.Render("→ "+test.Path)+dimStyle.Render("  clone_root, rules are off"))
+	default:
+		lines = append(lines, repoNameStyle.Render("→ "+test.Path)+dimStyle.Render("  clone_root, no rule matches"))
+	}
+	if len(test.Matches) > 1 && test.Enabled {
+		lines = append(lines, dimStyle.Render(fmt.Sprintf("%d rules match, · marks rules shadowed by an earlier one", len(test.Matches))))
+	}
+
+	if e.editing {
+		lines = append(lines, "")
+		lines = append(lines, configLabelStyle.Render(fmt.Sprintf("%-10s", "Pattern"))+e.inputs[ruleFieldPattern].View())
+		lines = append(lines, configLabelStyle.Render(fmt.Sprintf("%-10s", "Path"))+e.inputs[ruleFieldPath].View())
+		if err := e.candidate().Validate(); err != nil {
+			lines = append(lines, statusErrorStyle.Render(padOrTrim(err.Error(), innerW)))
+		} else {
+			lines = append(lines, localYesStyle.Render("valid"))
+		}
+		lines = append(lines, "")
+		lines = append(lines, keybindStyle.Render("tab switch field  enter apply  esc cancel"))
+	} else {
+		lines = append(lines, "")
+		lines = append(lines, keybindStyle.Render("a add  e edit  d delete  shift+↑↓ move  t test  w save  esc close"))
+	}
+
+	return overlayStyle.Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/wealthystudent/fuzzyrepo/index"
+)
+
+// Editing a rule's pattern in the rule editor must keep the settings only
+// the config file can set: hooks, editor and clone options
+func TestRuleEditorKeepsRuleSettings(t *testing.T) {
+	m := testModel(t)
+	shallow := CloneOptions{Depth: 1}
+	m.config.UseCloneRules = true
+	m.config.CloneRules = []CloneRule{{
+		CloneRule: index.CloneRule{Pattern: "^acme/", Path: "/src/acme", Clone: &shallow},
+		Hooks:     &Hooks{PostClone: []string{"make setup"}},
+		Editor:    []string{"code", "{path}"},
+	}}
+
+	var model tea.Model = m
+	keys := []tea.KeyMsg{
+		{Type: tea.KeyCtrlR},                      // Rule editor
+		{Type: tea.KeyEnter},                      // Edit the rule
+		{Type: tea.KeyRunes, Runes: []rune("co")}, // Pattern ^acme/co
+		{Type: tea.KeyEnter},                      // Keep the edit
+		{Type: tea.KeyRunes, Runes: []rune("w")},  // Save
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")}) // Config overlay
+	for _, key := range keys {
+		model, _ = model.Update(key)
+	}
+	if model.(Model).rules != nil {
+		t.Fatalf("rule editor still open: %s", model.(Model).message.Text)
+	}
+
+	saved, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.CloneRules) != 1 {
+		t.Fatalf("saved %d rules, want 1", len(saved.CloneRules))
+	}
+	rule := saved.CloneRules[0]
+	if rule.Pattern != "^acme/co" {
+		t.Errorf("pattern = %q, want %q", rule.Pattern, "^acme/co")
+	}
+	if rule.Hooks == nil || !slices.Equal(rule.Hooks.PostClone, []string{"make setup"}) {
+		t.Errorf("hooks = %+v, want post_clone [make setup]", rule.Hooks)
+	}
+	if !slices.Equal(rule.Editor, []string{"code", "{path}"}) {
+		t.Errorf("editor = %q, want [code {path}]", rule.Editor)
+	}
+	if rule.Clone == nil || rule.Clone.Depth != 1 {
+		t.Errorf("clone = %+v, want depth 1", rule.Clone)
+	}
+	if re := rule.Regexp(); re == nil || re.MatchString("acme/api") {
+		t.Error("the edited pattern is not the one matched")
+	}
+}
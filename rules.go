@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/wealthystudent/fuzzyrepo/index"
+)
+
+// ruleMatch is a clone rule that matches a repo, with the path it would produce
+type ruleMatch struct {
+	Index int // Position of the rule in clone_rules
+	Rule  CloneRule
+	Path  string
+}
+
+// ruleTest explains how the clone path of a repo is chosen
+type ruleTest struct {
+	FullName string
+	Enabled  bool        // use_clone_rules is on
+	Matches  []ruleMatch // Every valid rule matching FullName, in rule order
+	Invalid  []int       // Indices of rules whose pattern doesn't compile
+	Path     string      // Final GetClonePath result
+}
+
+// Winner returns the rule that decides the clone path, if any
+func (t ruleTest) Winner() (ruleMatch, bool) {
+	if !t.Enabled || len(t.Matches) == 0 {
+		return ruleMatch{}, false
+	}
+	return t.Matches[0], true
+}
+
+// testCloneRules matches fullName ("owner/repo") against every clone rule.
+// Unlike matchCloneRule it doesn't stop at the first match, so shadowed
+// rules can be shown too.
+func (c Config) testCloneRules(fullName string) ruleTest {
+	repoName := path.Base(fullName)
+	t := ruleTest{
+		FullName: fullName,
+		Enabled:  c.UseCloneRules,
+		Path:     c.GetClonePath(fullName, repoName),
+	}
+
+	for i, rule := range c.CloneRules {
+		re := rule.Regexp()
+		if re == nil {
+			t.Invalid = append(t.Invalid, i)
+			continue
+		}
+		match := re.FindStringSubmatchIndex(fullName)
+		if match == nil {
+			continue
+		}
+		t.Matches = append(t.Matches, ruleMatch{
+			Index: i,
+			Rule:  rule,
+			Path:  index.ExpandClonePath(rule.Path, fullName, repoName, re, match),
+		})
+	}
+
+	return t
+}
+
+// validFullName reports whether s looks like "owner/repo"
+func validFullName(s string) bool {
+	owner, repo, ok := strings.Cut(s, "/")
+	return ok && owner != "" && repo != "" && !strings.Contains(repo, "/")
+}
+
+// runRulesCommand implements "fuzzyrepo rules ..." and returns the exit code
+func runRulesCommand(args []string) int {
+	if len(args) != 2 || args[0] != "test" {
+		fmt.Fprintln(os.Stderr, "usage: fuzzyrepo rules test <owner/repo>")
+		return 2
+	}
+	fullName := args[1]
+	if !validFullName(fullName) {
+		fmt.Fprintf(os.Stderr, "invalid repo %q, expected owner/repo\n", fullName)
+		return 2
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "could not load config:", err)
+		return 1
+	}
+
+	printRuleTest(os.Stdout, config.testCloneRules(fullName))
+	return 0
+}
+
+// printRuleTest writes a rule test report for the CLI
+func printRuleTest(w io.Writer, t ruleTest) {
+	for _, i := range t.Invalid {
+		fmt.Fprintf(w, "rule %d: invalid pattern, skipped\n", i+1)
+	}
+
+	winner, hasWinner := t.Winner()
+	for _, m := range t.Matches {
+		note := "shadowed"
+		switch {
+		case !t.Enabled:
+			note = "clone rules disabled"
+		case hasWinner && m.Index == winner.Index:
+			note = "wins"
+		}
+		fmt.Fprintf(w, "rule %d  %-30s -> %s  (%s)\n", m.Index+1, m.Rule.Pattern, m.Path, note)
+	}
+
+	switch {
+	case len(t.Matches) == 0:
+		fmt.Fprintf(w, "no rule matches %s, using clone_root\n", t.FullName)
+	case !t.Enabled:
+		fmt.Fprintln(w, "use_clone_rules is off, using clone_root")
+	}
+	fmt.Fprintln(w, "clone path:", t.Path)
+}
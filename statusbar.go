@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	statusBarStyle = lipgloss.NewStyle().
+			Foreground(fgDimColor).
+			Background(bgColor)
+
+	statusActiveStyle = lipgloss.NewStyle().
+				Foreground(magentaColor).
+				Background(bgColor)
+
+	statusErrorStyle = lipgloss.NewStyle().
+				Foreground(redColor).
+				Background(bgColor)
+)
+
+// refreshSyncState reloads sync timestamps and checks for a running detached sync
+func (m *Model) refreshSyncState() {
+	if meta, err := LoadMetadata(); err == nil {
+		// A sync failed since the last check, e.g. the background sync
+		if meta.LastErrorAt.After(m.meta.LastErrorAt) {
+			m.lastSyncErr = meta.LastError
+		}
+		m.meta = meta
+	}
+	// A manual refresh holds the sync lock in this process
+	m.syncRunning = isSyncRunning() && syncLockPID() != os.Getpid()
+}
+
+// watchBackgroundSync shows text until the detached sync with the given PID
+// reports progress, see followBackgroundSync
+func (m *Model) watchBackgroundSync(pid int, text string) {
+	m.setMessage(text, InfoLevel)
+	m.refreshing = true
+	m.bgSyncPID = pid
+	m.bgSyncMsgID = m.message.ID
+}
+
+// followBackgroundSync shows the latest step of the detached sync this UI
+// started in place of its message, and reports how the sync ended. A
+// successful sync is picked up by the cache reload.
+func (m *Model) followBackgroundSync() {
+	if m.bgSyncPID == 0 {
+		return
+	}
+	event, ok := lastSyncEvent(m.bgSyncPID)
+	if isProcessRunning(m.bgSyncPID) {
+		if ok && event.Step != "" && m.message.ID == m.bgSyncMsgID {
+			m.message.Text = "Syncing: " + event.Step
+		}
+		return
+	}
+
+	m.bgSyncPID = 0
+	m.refreshing = false
+	switch {
+	case ok && event.Done && event.Error == "":
+		// The cache reload reports the new repos
+	case ok && event.Done:
+		m.lastSyncErr = event.Error
+		m.setMessageWithHint("background sync failed: "+event.Error, ErrorLevel, "press space r to retry")
+	case ok:
+		m.lastSyncErr = "stopped while " + event.Step
+		m.setMessageWithHint("background sync stopped while "+event.Step, ErrorLevel, "press space r to retry")
+	default:
+		m.lastSyncErr = "exited without reporting progress"
+		m.setMessageWithHint("background sync exited without reporting progress", ErrorLevel, "run fuzzyrepo --sync-remote to see why")
+	}
+}
+
+// syncAge renders how long ago t happened, or "never" for the zero time
+func syncAge(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	age := formatAge(t)
+	if age == "now" {
+		return "just now"
+	}
+	return age + " ago"
+}
+
+// renderStatusBar renders the persistent status line above the search prompt:
+// repo counts, sync ages, background activity, filters and the last sync error
+func (m Model) renderStatusBar(width int) string {
+	sep := statusBarStyle.Render(" · ")
+
+	parts := []string{
+		statusBarStyle.Render(fmt.Sprintf("%d/%d repos", len(m.all), len(m.cache))),
+		statusBarStyle.Render("remote " + syncAge(m.meta.LastRemoteSync)),
+		statusBarStyle.Render("local " + syncAge(m.meta.LastLocalScan)),
+	}
+
+	switch {
+	case m.syncRunning:
+		parts = append(parts, statusActiveStyle.Render("syncing in background"))
+	case m.refreshing:
+		parts = append(parts, statusActiveStyle.Render("refreshing"))
+	}
+
+	if summary := m.filterSummary(); summary != "" {
+		parts = append(parts, statusBarStyle.Render(summary))
+	}
+
+	if m.lastSyncErr != "" {
+		parts = append(parts, statusErrorStyle.Render("sync failed: "+firstLine(m.lastSyncErr)))
+	}
+
+	line := bgOnlyStyle.Render(" ") + strings.Join(parts, sep)
+	if lipgloss.Width(line) > width {
+		line = padOrTrim(line, width)
+	}
+	return padLineToWidth(line, width, bgOnlyStyle)
+}
+
+// firstLine returns s up to the first newline
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
@@ -51,6 +51,14 @@ var (
 				Foreground(redColor).
 				Background(bgSelectedColor)
 
+	dirtyStyle = lipgloss.NewStyle().
+			Foreground(yellowColor).
+			Background(bgColor)
+
+	dirtyCursorStyle = lipgloss.NewStyle().
+				Foreground(yellowColor).
+				Background(bgSelectedColor)
+
 	headerStyle = lipgloss.NewStyle().
 			Foreground(cyanColor).
 			Background(bgColor)
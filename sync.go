@@ -2,14 +2,21 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
+	"strings"
 	"syscall"
+	"time"
+
+	"github.com/wealthystudent/fuzzyrepo/index"
 )
 
 // Sync lock file path
@@ -17,30 +24,32 @@ func getSyncLockPath() string {
 	return filepath.Join(getCacheDir(), "sync.lock")
 }
 
-// isSyncRunning checks if a sync process is currently running
-// Returns true if lock file exists and the PID is still alive
+// getSyncRunningPath is the lock a remote sync holds exclusively while it
+// runs. isSyncRunning checks it with a shared lock, so checking never makes
+// a starting sync find the sync lock taken.
+func getSyncRunningPath() string {
+	return filepath.Join(getCacheDir(), "sync-running.lock")
+}
+
+// isSyncRunning checks if a sync, in this or another process, is running
 func isSyncRunning() bool {
-	lockPath := getSyncLockPath()
-	data, err := os.ReadFile(lockPath)
+	lock, err := index.TryRLockFile(getSyncRunningPath())
 	if err != nil {
-		return false // No lock file means no sync running
+		return errors.Is(err, index.ErrLocked)
 	}
+	_ = lock.Unlock()
+	return false
+}
 
-	pid, err := strconv.Atoi(string(data))
+// syncLockPID returns the PID the sync lock holder wrote into the lock
+// file, 0 if none. Only meaningful while isSyncRunning.
+func syncLockPID() int {
+	data, err := os.ReadFile(getSyncLockPath())
 	if err != nil {
-		// Invalid lock file, remove it
-		_ = os.Remove(lockPath)
-		return false
-	}
-
-	// Check if process is still running
-	if !isProcessRunning(pid) {
-		// Stale lock file, remove it
-		_ = os.Remove(lockPath)
-		return false
+		return 0
 	}
-
-	return true
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return pid
 }
 
 // isProcessRunning checks if a process with the given PID is running
@@ -61,140 +70,330 @@ func isProcessRunning(pid int) bool {
 	return true
 }
 
-// acquireSyncLock creates a lock file with the current PID using atomic operations.
-// Returns true if lock was acquired, false if sync is already running.
-// Uses O_CREATE|O_EXCL for atomic lock creation to prevent race conditions.
-func acquireSyncLock() bool {
-	lockPath := getSyncLockPath()
-	dir := filepath.Dir(lockPath)
-	if err := os.MkdirAll(dir, 0o755); err != nil {
-		return false
-	}
+// syncLock is held by a running remote sync, see acquireSyncLock
+type syncLock struct {
+	lock    *index.Lock // Keeps other syncs out
+	running *index.Lock // Tells isSyncRunning that a sync runs
+}
 
-	// First, check if there's an existing lock with a live process
-	if data, err := os.ReadFile(lockPath); err == nil {
-		if pid, err := strconv.Atoi(string(data)); err == nil {
-			if isProcessRunning(pid) {
-				return false // Another sync is running
-			}
-		}
-		// Stale lock file, remove it
-		_ = os.Remove(lockPath)
+// acquireSyncLock takes the sync lock without waiting and writes the
+// current PID into the lock file for syncLockPID. Returns index.ErrLocked
+// if another sync holds it. The locks are released when the process exits,
+// so a crashed sync doesn't block the next one.
+func acquireSyncLock() (*syncLock, error) {
+	lock, err := index.TryLockFile(getSyncLockPath())
+	if err != nil {
+		return nil, err
 	}
-
-	// Try to create lock file atomically with O_CREATE|O_EXCL
-	// This ensures only one process can create the file
-	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	// Only a check of isSyncRunning may hold it, for a moment
+	running, err := index.LockFile(getSyncRunningPath())
 	if err != nil {
-		// Lock file was created by another process between our check and create
-		return false
+		_ = lock.Unlock()
+		return nil, err
 	}
-	defer f.Close()
 
-	pid := os.Getpid()
-	if _, err := f.WriteString(strconv.Itoa(pid)); err != nil {
-		// Failed to write PID, clean up and return false
-		_ = os.Remove(lockPath)
-		return false
+	f := lock.File()
+	if err := f.Truncate(0); err == nil {
+		_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0)
 	}
+	return &syncLock{lock: lock, running: running}, nil
+}
 
-	return true
+// releaseSyncLock clears the PID and releases the locks. The lock files
+// stay, see index.Lock.Unlock.
+func releaseSyncLock(l *syncLock) {
+	_ = l.lock.File().Truncate(0)
+	_ = l.running.Unlock()
+	_ = l.lock.Unlock()
 }
 
-// releaseSyncLock removes the lock file
-func releaseSyncLock() {
-	_ = os.Remove(getSyncLockPath())
+var ErrSyncRunning = errors.New("another sync is already running")
+
+// syncReporter receives the progress of a sync, see runSync
+type syncReporter interface {
+	// step describes what the sync is doing, e.g. "fetching GitHub repos"
+	step(msg string)
+	// localScanned delivers the cached repos merged with a fresh local
+	// scan, before a remote sync fetches GitHub
+	localScanned(repos []Repository)
+	// finished delivers the saved index, or the error that ended the sync
+	finished(repos []Repository, err error)
 }
 
-// runRemoteSync performs a full remote sync operation
-// This is called when fuzzyrepo is invoked with --sync-remote flag
-func runRemoteSync() {
-	// Try to acquire lock
-	if !acquireSyncLock() {
-		fmt.Fprintln(os.Stderr, "Another sync is already running")
-		os.Exit(1)
+// runSync is the sync engine behind the detached --sync-remote process, the
+// manual refresh, the startup local scan and the daemon. It rescans the repo
+// roots, fetches GitHub if remote is set (otherwise the cached remote repos
+// are kept), writes the cache atomically, records the outcome in the
+// metadata and the sync log. The result is also passed to r.
+//
+// A remote sync holds the sync lock, so GitHub is fetched once at a time. A
+// local scan doesn't need it: it updates the cache in one transaction, so
+// it can run alongside a remote sync instead of being skipped.
+func runSync(ctx context.Context, config Config, remote bool, r syncReporter) ([]Repository, error) {
+	if remote {
+		lock, err := acquireSyncLock()
+		if errors.Is(err, index.ErrLocked) {
+			err = ErrSyncRunning
+		}
+		if err != nil {
+			r.finished(nil, err)
+			return nil, err
+		}
+		defer releaseSyncLock(lock)
 	}
-	defer releaseSyncLock()
 
-	// Load config
-	config, err := LoadConfig()
+	start := time.Now()
+	repos, counts, err := syncRepos(ctx, config, remote, r)
+	took := time.Since(start)
+
+	logSync(remote, took, len(repos), counts, err)
+	saveErr := UpdateMetadata(func(meta *CacheMetadata) {
+		meta.recordSync(remote, took, counts, err)
+	})
+	if saveErr != nil {
+		// Not fatal, the cache was saved successfully
+		r.step(fmt.Sprintf("could not save metadata: %v", saveErr))
+	}
+
+	r.finished(repos, err)
+	return repos, err
+}
+
+// syncRepos does the work of runSync. It returns the saved index and the
+// number of repos found by each source.
+//
+// Sources are listed outside the lock of repos.json, which is only held to
+// merge them with the file's current contents and save: a local scan keeps
+// the GitHub repos of the file, a remote sync the local clones, so a local
+// scan saved while GitHub was fetched is not lost.
+func syncRepos(ctx context.Context, config Config, remote bool, r syncReporter) ([]Repository, map[string]int, error) {
+	counts := make(map[string]int)
+
+	local, err := reportedProvider{
+		Provider: config.localProvider(),
+		step:     "scanning local repos",
+		r:        r,
+		counts:   counts,
+	}.List(ctx)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Failed to load config:", err)
-		os.Exit(1)
+		return nil, nil, fmt.Errorf("local: %w", err)
 	}
+	merged, err := mergeIntoCache(ctx, func(cached []Repository) []index.Provider {
+		return []index.Provider{listedProvider{"local", local}, cachedProvider{cached}}
+	})
+	if err != nil || !remote {
+		return merged, counts, err
+	}
+	r.localScanned(merged)
 
-	ctx := context.Background()
+	github := config.gitHubProvider()
+	github.Progress = func(affiliation string, page, repos int) {
+		r.step(fmt.Sprintf("fetching %s page %d, %d repos so far", affiliation, page, repos))
+	}
+	remoteRepos, err := reportedProvider{
+		Provider: github,
+		step:     "fetching GitHub repos",
+		r:        r,
+		counts:   counts,
+	}.List(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("github: %w", err)
+	}
+	merged, err = mergeIntoCache(ctx, func(cached []Repository) []index.Provider {
+		return []index.Provider{cachedClonesProvider{cached}, listedProvider{"github", remoteRepos}}
+	})
+	return merged, counts, err
+}
 
-	// Get GitHub client
-	githubClient, err := getGithubClient(ctx)
+// mergeIntoCache replaces the cached repos with index.Sync over the
+// providers sources returns for them, holding the lock of repos.json from
+// loading to saving
+func mergeIntoCache(ctx context.Context, sources func(cached []Repository) []index.Provider) ([]Repository, error) {
+	var merged []Repository
+	err := cacheStore().UpdateRepos(func(cached []Repository) ([]Repository, error) {
+		var err error
+		merged, err = index.Sync(ctx, sources(cached)...)
+		return merged, err
+	})
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Failed to get GitHub client:", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("write cache: %w", err)
 	}
+	return merged, nil
+}
+
+// reportedProvider reports listing a provider as a sync step and counts the
+// repos it found
+type reportedProvider struct {
+	index.Provider
+	step   string
+	r      syncReporter
+	counts map[string]int
+}
 
-	// Fetch remote repositories
-	remoteRepos, err := getRemoteRepositories(ctx, githubClient, config)
+func (p reportedProvider) List(ctx context.Context) ([]Repository, error) {
+	p.r.step(p.step)
+	repos, err := p.Provider.List(ctx)
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Failed to fetch remote repos:", err)
-		os.Exit(1)
+		return nil, err
 	}
+	p.counts[p.Name()] = len(repos)
+	return repos, nil
+}
 
-	// Scan local repositories
-	localRepos := indexLocalRepos(config.GetRepoRoots())
+// listedProvider serves repos listed before the cache was locked
+type listedProvider struct {
+	name  string
+	repos []Repository
+}
 
-	// Merge repos
-	merged := mergeRepos(localRepos, remoteRepos)
+func (p listedProvider) Name() string {
+	return p.name
+}
 
-	// Save to cache
-	cacheDir := getCacheDir()
-	cachePath := getCachePath()
+func (p listedProvider) List(context.Context) ([]Repository, error) {
+	return p.repos, nil
+}
 
-	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
-		fmt.Fprintln(os.Stderr, "Failed to create cache dir:", err)
-		os.Exit(1)
+// cachedProvider lists the GitHub repos of the cache without their local
+// clones, so a local scan can merge them with the clones found now
+type cachedProvider struct {
+	repos []Repository
+}
+
+func (p cachedProvider) Name() string {
+	return "cache"
+}
+
+func (p cachedProvider) List(context.Context) ([]Repository, error) {
+	var repos []Repository
+	for _, r := range p.repos {
+		if r.Affiliation == index.AffiliationLocal {
+			continue
+		}
+		r.LocalPath = ""
+		r.ExistsLocal = false
+		repos = append(repos, r)
 	}
+	return repos, nil
+}
+
+// cachedClonesProvider lists the local clones of the cache the way a local
+// scan finds them, so a remote sync can merge them with the GitHub repos
+// fetched now
+type cachedClonesProvider struct {
+	repos []Repository
+}
 
-	b, err := json.MarshalIndent(merged, "", "  ")
+func (p cachedClonesProvider) Name() string {
+	return "cache"
+}
+
+func (p cachedClonesProvider) List(context.Context) ([]Repository, error) {
+	var repos []Repository
+	for _, r := range p.repos {
+		if !r.ExistsLocal {
+			continue
+		}
+		clone := Repository{
+			Owner:       r.Owner,
+			Name:        r.Name,
+			FullName:    r.FullName,
+			SSHURL:      r.SSHURL,
+			LocalPath:   r.LocalPath,
+			ExistsLocal: true,
+			Affiliation: index.AffiliationLocal,
+		}
+		clone.ComputeSearchText()
+		repos = append(repos, clone)
+	}
+	return repos, nil
+}
+
+func getSyncLogPath() string {
+	return filepath.Join(getCacheDir(), "sync.log")
+}
+
+// logSync appends the outcome of a sync to the sync log, e.g.
+// "2026-01-02T15:04:05Z remote sync ok: 612 repos (github 600, local 15) in 3.2s [pid 123]"
+func logSync(remote bool, took time.Duration, repos int, counts map[string]int, err error) {
+	kind := "local scan"
+	if remote {
+		kind = "remote sync"
+	}
+	took = took.Round(time.Millisecond)
+
+	var result string
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Failed to marshal repos:", err)
-		os.Exit(1)
+		result = fmt.Sprintf("failed after %s: %v", took, err)
+	} else {
+		sources := make([]string, 0, len(counts))
+		for _, source := range slices.Sorted(maps.Keys(counts)) {
+			sources = append(sources, fmt.Sprintf("%s %d", source, counts[source]))
+		}
+		result = fmt.Sprintf("ok: %d repos (%s) in %s", repos, strings.Join(sources, ", "), took)
 	}
 
-	tmpPath := cachePath + ".tmp"
-	if err := os.WriteFile(tmpPath, b, 0o600); err != nil {
-		fmt.Fprintln(os.Stderr, "Failed to write cache:", err)
-		os.Exit(1)
+	_ = appendLog(getSyncLogPath(), fmt.Sprintf("%s %s %s [pid %d]\n",
+		time.Now().Format(time.RFC3339), kind, result, os.Getpid()))
+}
+
+// writerReporter prints sync progress as lines, for the --sync-remote process
+type writerReporter struct {
+	w io.Writer
+}
+
+func (r writerReporter) step(msg string) {
+	fmt.Fprintln(r.w, msg)
+}
+
+func (r writerReporter) localScanned([]Repository) {}
+
+func (r writerReporter) finished(repos []Repository, err error) {
+	if err != nil {
+		fmt.Fprintln(r.w, "Sync failed:", err)
+		return
 	}
+	fmt.Fprintf(r.w, "Synced %d repositories\n", len(repos))
+}
+
+// nopReporter ignores the progress, for syncs whose result is used directly
+type nopReporter struct{}
+
+func (nopReporter) step(string)                  {}
+func (nopReporter) localScanned([]Repository)    {}
+func (nopReporter) finished([]Repository, error) {}
+
+// runRemoteSync performs a full remote sync operation
+// This is called when fuzzyrepo is invoked with --sync-remote flag
+func runRemoteSync() {
+	// The progress file lets the UI that spawned us follow along
+	reporter := multiReporter{writerReporter{os.Stderr}, &fileReporter{pid: os.Getpid()}}
 
-	if err := os.Rename(tmpPath, cachePath); err != nil {
-		fmt.Fprintln(os.Stderr, "Failed to rename cache:", err)
+	// Load config
+	config, err := LoadConfig()
+	if err != nil {
+		reporter.finished(nil, fmt.Errorf("load config: %w", err))
 		os.Exit(1)
 	}
 
-	// Update metadata
-	meta, _ := LoadMetadata()
-	meta.UpdateRemoteSyncTime()
-	meta.UpdateLocalScanTime()
-	if err := SaveMetadata(meta); err != nil {
-		fmt.Fprintln(os.Stderr, "Failed to save metadata:", err)
-		// Don't exit, cache was saved successfully
+	if _, err := runSync(context.Background(), config, true, reporter); err != nil {
+		os.Exit(1)
 	}
-
-	fmt.Printf("Synced %d repositories\n", len(merged))
 }
 
 // spawnDetachedSync starts a background sync process that continues even after
-// the main process exits. Returns true if spawn was successful.
-func spawnDetachedSync() bool {
+// the main process exits. It reports its progress in the progress file.
+// Returns the PID of the process, 0 if none was started.
+func spawnDetachedSync() int {
 	// Check if sync is already running
 	if isSyncRunning() {
-		return false
+		return 0
 	}
 
 	// Get the path to our own executable
 	executable, err := os.Executable()
 	if err != nil {
-		return false
+		return 0
 	}
 
 	// Create the command
@@ -205,61 +404,19 @@ func spawnDetachedSync() bool {
 		Setpgid: true, // Create new process group
 	}
 
-	// Redirect output to null (we don't want to see it)
+	// Redirect output to null, progress goes to the progress file
 	cmd.Stdout = nil
 	cmd.Stderr = nil
 	cmd.Stdin = nil
 
 	// Start the process (don't wait for it)
 	if err := cmd.Start(); err != nil {
-		return false
-	}
-
-	// Detach - we don't call cmd.Wait() so the process continues independently
-	return true
-}
-
-// runLocalScan performs a local-only scan (no GitHub API calls)
-// This is fast and can be run inline on startup
-// Returns the merged repos and any error
-func runLocalScan(config Config, existingRepos []Repository) ([]Repository, error) {
-	// Scan local repositories
-	localRepos := indexLocalRepos(config.GetRepoRoots())
-
-	// Merge with existing cached repos
-	merged := mergeRepos(localRepos, existingRepos)
-
-	// Save to cache
-	if err := saveReposToCache(merged); err != nil {
-		return merged, err
-	}
-
-	// Update metadata (only local scan time)
-	meta, _ := LoadMetadata()
-	meta.UpdateLocalScanTime()
-	_ = SaveMetadata(meta)
-
-	return merged, nil
-}
-
-// saveReposToCache saves the repos slice to the cache file atomically
-func saveReposToCache(repos []Repository) error {
-	cacheDir := getCacheDir()
-	cachePath := getCachePath()
-
-	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
-		return err
-	}
-
-	b, err := json.MarshalIndent(repos, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	tmpPath := cachePath + ".tmp"
-	if err := os.WriteFile(tmpPath, b, 0o600); err != nil {
-		return err
+		return 0
 	}
 
-	return os.Rename(tmpPath, cachePath)
+	// The process continues independently when we exit. Reap it if it
+	// finishes first, so it doesn't linger as a zombie that still looks
+	// alive to isProcessRunning.
+	go func() { _ = cmd.Wait() }()
+	return cmd.Process.Pid
 }
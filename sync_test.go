@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/wealthystudent/fuzzyrepo/index"
+)
+
+func TestLocalScanKeepsGitHubRepos(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+
+	root := filepath.Join(home, "src")
+	gitDir := filepath.Join(root, "api", ".git")
+	if err := os.MkdirAll(gitDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gitConfig := "[remote \"origin\"]\n\turl = git@github.com:acme/api.git\n"
+	if err := os.WriteFile(filepath.Join(gitDir, "config"), []byte(gitConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := cacheStore().SaveRepos([]Repository{
+		{Owner: "acme", Name: "api", FullName: "acme/api", Affiliation: "owner", Stars: 4},
+		{Owner: "acme", Name: "web", FullName: "acme/web", Affiliation: "owner", LocalPath: "/deleted/web", ExistsLocal: true},
+		{Owner: "local", Name: "old", FullName: "local/old", Affiliation: "local", LocalPath: "/deleted/old", ExistsLocal: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig()
+	config.RepoRoots = []string{root}
+	repos, counts, err := syncRepos(context.Background(), config, false, nopReporter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts["local"] != 1 {
+		t.Errorf("counts = %v, want 1 local repo", counts)
+	}
+
+	saved, err := loadRepoCache()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, got := range [][]Repository{repos, saved} {
+		merged := make(map[string]Repository)
+		for _, r := range got {
+			merged[r.FullName] = r
+		}
+		if len(merged) != 2 {
+			t.Errorf("local scan kept %d repos, want acme/api and acme/web: %v", len(merged), got)
+		}
+		if api := merged["acme/api"]; !api.ExistsLocal || api.LocalPath != filepath.Join(root, "api") || api.Stars != 4 {
+			t.Errorf("acme/api = %+v, want its GitHub metadata and clone", api)
+		}
+		if web := merged["acme/web"]; web.ExistsLocal || web.Affiliation != "owner" {
+			t.Errorf("acme/web = %+v, want the GitHub repo without the deleted clone", web)
+		}
+	}
+}
+
+func TestLocalScanDuringRemoteSync(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	if err := os.MkdirAll(getCacheDir(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := acquireSyncLock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer releaseSyncLock(lock)
+
+	config := DefaultConfig()
+	config.RepoRoots = []string{t.TempDir()}
+	if _, err := runSync(context.Background(), config, false, nopReporter{}); err != nil {
+		t.Errorf("local scan during a remote sync = %v, want it to run", err)
+	}
+	if _, err := runSync(context.Background(), config, true, nopReporter{}); !errors.Is(err, ErrSyncRunning) {
+		t.Errorf("remote sync during a remote sync = %v, want %v", err, ErrSyncRunning)
+	}
+}
+
+// TestHelperProcess runs in the child processes of the tests below, picked
+// by FUZZYREPO_TEST_HELPER. HOME is inherited, so they share the cache dir.
+func TestHelperProcess(t *testing.T) {
+	switch os.Getenv("FUZZYREPO_TEST_HELPER") {
+	case "":
+		t.Skip("only run as a helper process")
+	case "update_metadata":
+		for range updatesPerProcess {
+			err := UpdateMetadata(func(meta *CacheMetadata) {
+				if meta.RepoCounts == nil {
+					meta.RepoCounts = make(map[string]int)
+				}
+				meta.RepoCounts["test"]++
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	case "hold_sync_lock":
+		lock, err := acquireSyncLock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer releaseSyncLock(lock)
+		fmt.Println("locked")
+		// Hold the lock until the parent closes stdin
+		_, _ = io.Copy(io.Discard, os.Stdin)
+	}
+}
+
+const updatesPerProcess = 50
+
+// helperProcess returns a command running TestHelperProcess as helper
+func helperProcess(helper string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	cmd.Env = append(os.Environ(), "FUZZYREPO_TEST_HELPER="+helper)
+	return cmd
+}
+
+func TestUpdateMetadataConcurrently(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	const processes = 8
+	cmds := make([]*exec.Cmd, processes)
+	for i := range cmds {
+		cmds[i] = helperProcess("update_metadata")
+		if err := cmds[i].Start(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	meta, err := LoadMetadata()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := meta.RepoCounts["test"]; got != processes*updatesPerProcess {
+		t.Errorf("counted %d updates, want %d", got, processes*updatesPerProcess)
+	}
+}
+
+func TestSyncLockHeldByAnotherProcess(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	cmd := helperProcess("hold_sync_lock")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil || line != "locked\n" {
+		t.Fatalf("helper answered %q, %v", line, err)
+	}
+
+	if !isSyncRunning() {
+		t.Error("isSyncRunning = false while another process syncs")
+	}
+	if pid := syncLockPID(); pid != cmd.Process.Pid {
+		t.Errorf("syncLockPID = %d, want %d", pid, cmd.Process.Pid)
+	}
+	if lock, err := acquireSyncLock(); !errors.Is(err, index.ErrLocked) {
+		if err == nil {
+			releaseSyncLock(lock)
+		}
+		t.Fatalf("acquireSyncLock = %v, want %v", err, index.ErrLocked)
+	}
+
+	stdin.Close()
+	if err := cmd.Wait(); err != nil {
+		t.Fatal(err)
+	}
+
+	if isSyncRunning() {
+		t.Error("isSyncRunning = true after the sync ended")
+	}
+	lock, err := acquireSyncLock()
+	if err != nil {
+		t.Fatalf("acquireSyncLock after the sync ended = %v", err)
+	}
+	releaseSyncLock(lock)
+}
+
+func TestRemoteSyncKeepsCachedClones(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	// Saved by a local scan while GitHub was fetched
+	err := cacheStore().SaveRepos([]Repository{
+		{Owner: "acme", Name: "api", FullName: "acme/api", Affiliation: "owner", LocalPath: "/src/api", ExistsLocal: true},
+		{Owner: "local", Name: "notes", FullName: "local/notes", Affiliation: "local", LocalPath: "/src/notes", ExistsLocal: true},
+		{Owner: "acme", Name: "gone", FullName: "acme/gone", Affiliation: "owner"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetched := []Repository{
+		{Owner: "acme", Name: "api", FullName: "acme/api", Affiliation: "owner", Stars: 7},
+		{Owner: "acme", Name: "web", FullName: "acme/web", Affiliation: "owner"},
+	}
+	repos, err := mergeIntoCache(context.Background(), func(cached []Repository) []index.Provider {
+		return []index.Provider{cachedClonesProvider{cached}, listedProvider{"github", fetched}}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	merged := make(map[string]Repository)
+	for _, r := range repos {
+		merged[r.FullName] = r
+	}
+	if len(merged) != 3 {
+		t.Errorf("merged %d repos, want acme/api, acme/web and local/notes: %v", len(merged), repos)
+	}
+	if api := merged["acme/api"]; !api.ExistsLocal || api.LocalPath != "/src/api" || api.Stars != 7 {
+		t.Errorf("acme/api = %+v, want the fetched repo with the cached clone", api)
+	}
+	if notes := merged["local/notes"]; !notes.ExistsLocal {
+		t.Errorf("local/notes = %+v, want the cached local repo", notes)
+	}
+	if saved, _ := loadRepoCache(); len(saved) != len(repos) {
+		t.Errorf("saved %d repos, merged %d", len(saved), len(repos))
+	}
+}
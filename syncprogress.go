@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// getSyncProgressPath returns the file a detached sync streams its progress
+// to, one JSON event per line
+func getSyncProgressPath() string {
+	return filepath.Join(getCacheDir(), "sync-progress.jsonl")
+}
+
+// syncEvent is one line of the progress file
+type syncEvent struct {
+	Time  time.Time `json:"time"`
+	PID   int       `json:"pid"`             // Process running the sync
+	Step  string    `json:"step,omitempty"`  // What the sync is doing
+	Done  bool      `json:"done,omitempty"`  // The sync finished, see Error
+	Repos int       `json:"repos,omitempty"` // Repos in the saved index
+	Error string    `json:"error,omitempty"` // Why the sync failed
+}
+
+// multiReporter passes the progress of a sync to several reporters
+type multiReporter []syncReporter
+
+func (m multiReporter) step(msg string) {
+	for _, r := range m {
+		r.step(msg)
+	}
+}
+
+func (m multiReporter) localScanned(repos []Repository) {
+	for _, r := range m {
+		r.localScanned(repos)
+	}
+}
+
+func (m multiReporter) finished(repos []Repository, err error) {
+	for _, r := range m {
+		r.finished(repos, err)
+	}
+}
+
+// fileReporter writes sync events to the progress file. The file is
+// started over by the first step, which runs once the sync lock is held;
+// a sync that ends before that (refused by the lock, or a bad config)
+// appends its result to the file of the sync holding the lock.
+type fileReporter struct {
+	pid int
+	f   *os.File
+}
+
+func (r *fileReporter) step(msg string) {
+	if r.f == nil {
+		_ = os.MkdirAll(getCacheDir(), 0o755)
+		f, err := os.Create(getSyncProgressPath())
+		if err != nil {
+			return
+		}
+		r.f = f
+	}
+	r.write(syncEvent{Step: msg})
+}
+
+func (r *fileReporter) localScanned([]Repository) {}
+
+func (r *fileReporter) finished(repos []Repository, err error) {
+	if r.f == nil {
+		f, openErr := os.OpenFile(getSyncProgressPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if openErr != nil {
+			return
+		}
+		r.f = f
+	}
+	defer r.f.Close()
+
+	event := syncEvent{Done: true, Repos: len(repos)}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	r.write(event)
+}
+
+// write appends an event as a single write, so readers never see half a line
+func (r *fileReporter) write(event syncEvent) {
+	event.Time = time.Now()
+	event.PID = r.pid
+	b, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	_, _ = r.f.Write(append(b, '\n'))
+}
+
+// lastSyncEvent returns the latest event of the sync run by pid
+func lastSyncEvent(pid int) (syncEvent, bool) {
+	f, err := os.Open(getSyncProgressPath())
+	if err != nil {
+		return syncEvent{}, false
+	}
+	defer f.Close()
+
+	var last syncEvent
+	found := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e syncEvent
+		if json.Unmarshal(scanner.Bytes(), &e) == nil && e.PID == pid {
+			last = e
+			found = true
+		}
+	}
+	return last, found
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Open strategies, set with open_strategy
+const (
+	openEditor      = "editor"       // $EDITOR, or the running Neovim when inside one
+	openTmuxSession = "tmux_session" // Switch to (or create) a tmux session for the repo
+	openTmuxWindow  = "tmux_window"  // Switch to (or create) a window in the current tmux session
+)
+
+var openStrategies = []string{openEditor, openTmuxSession, openTmuxWindow}
+
+var ErrTmuxFailed = errors.New("tmux failed")
+
+// OpenRepo opens a local repo with the given open strategy
+func OpenRepo(path string, repo Repository, config Config, strategy string) error {
+	switch strategy {
+	case openTmuxSession:
+		return OpenInTmuxSession(path, tmuxRepoName(repo))
+	case openTmuxWindow:
+		return OpenInTmuxWindow(path, tmuxRepoName(repo))
+	}
+	return OpenInEditor(path, repo, config)
+}
+
+// tmuxRepoName names the tmux session or window of repo after its owner and
+// name, so same-named repos of different owners don't share one
+func tmuxRepoName(repo Repository) string {
+	if repo.Owner == "" {
+		return repo.Name
+	}
+	return repo.Owner + "_" + repo.Name
+}
+
+// tmuxName turns a repo name into a tmux session or window name.
+// tmux uses '.' and ':' in targets, so they can't be part of a name.
+func tmuxName(repoName string) string {
+	return strings.NewReplacer(".", "_", ":", "_").Replace(repoName)
+}
+
+// insideTmux reports whether fuzzyrepo runs inside a tmux client
+func insideTmux() bool {
+	return os.Getenv("TMUX") != ""
+}
+
+// runTmux runs a tmux command, returning its trimmed output
+func runTmux(args ...string) (string, error) {
+	out, err := exec.Command("tmux", args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return "", fmt.Errorf("%w: tmux %s: %s", ErrTmuxFailed, args[0], msg)
+		}
+		return "", fmt.Errorf("%w: tmux %s: %v", ErrTmuxFailed, args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// OpenInTmuxSession switches to the tmux session named name (see
+// tmuxRepoName), creating it with path as working directory if needed.
+// Outside tmux the session is attached in the current terminal.
+func OpenInTmuxSession(path, name string) error {
+	session := tmuxName(name)
+	// "=" makes tmux match the name exactly instead of as a prefix
+	target := "=" + session
+
+	if _, err := runTmux("has-session", "-t", target); err != nil {
+		if _, err := runTmux("new-session", "-d", "-s", session, "-c", path); err != nil {
+			return err
+		}
+	}
+
+	if insideTmux() {
+		_, err := runTmux("switch-client", "-t", target)
+		return err
+	}
+
+	cmd := exec.Command("tmux", "attach-session", "-t", target)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// OpenInTmuxWindow switches to the window named name in the current tmux
+// session, creating it if needed. Outside tmux there is no current
+// session, so it opens a session instead.
+func OpenInTmuxWindow(path, name string) error {
+	if !insideTmux() {
+		return OpenInTmuxSession(path, name)
+	}
+
+	window := tmuxName(name)
+	out, err := runTmux("list-windows", "-F", "#{window_name}")
+	if err != nil {
+		return err
+	}
+	if slices.Contains(strings.Split(out, "\n"), window) {
+		_, err := runTmux("select-window", "-t", ":="+window)
+		return err
+	}
+
+	_, err = runTmux("new-window", "-n", window, "-c", path)
+	return err
+}
@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestTmuxRepoName(t *testing.T) {
+	tests := []struct {
+		repo Repository
+		want string
+	}{
+		{Repository{Owner: "a", Name: "api"}, "a_api"},
+		{Repository{Owner: "b", Name: "api"}, "b_api"},
+		{Repository{Owner: "acme", Name: "web.app"}, "acme_web_app"},
+		{Repository{Name: "scratch"}, "scratch"},
+	}
+	for _, tt := range tests {
+		if got := tmuxName(tmuxRepoName(tt.repo)); got != tt.want {
+			t.Errorf("tmux name of %s/%s = %q, want %q", tt.repo.Owner, tt.repo.Name, got, tt.want)
+		}
+	}
+}
@@ -1,28 +1,51 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"sort"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"github.com/sahilm/fuzzy"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/wealthystudent/fuzzyrepo/index"
 )
 
 type reposUpdatedMsg []Repository
 type localReposUpdatedMsg []Repository
 type refreshStartedMsg struct{}
 type refreshFinishedMsg struct{}
-type localRefreshDoneMsg struct{}
+type syncProgressMsg string // Step of a running sync, see syncReporter
 type errorMsg struct{ err error }
-type cacheCheckTickMsg struct{} // Periodic tick to check cache file changes
-type clearMessageMsg struct{}   // Timer to clear status message
-type configEditedMsg struct{}   // Config file was edited externally
+type cacheCheckTickMsg struct{}       // Periodic tick to check cache file changes
+type clearMessageMsg struct{ id int } // Timer to clear status message
+type configEditedMsg struct{}         // Config file was edited externally
+
+// tuiReporter forwards the progress of a sync to the UI as messages
+type tuiReporter struct {
+	msgs chan<- tea.Msg
+}
+
+func (r tuiReporter) step(msg string) {
+	r.msgs <- syncProgressMsg(msg)
+}
+
+func (r tuiReporter) localScanned(repos []Repository) {
+	r.msgs <- localReposUpdatedMsg(repos)
+}
+
+func (r tuiReporter) finished(repos []Repository, err error) {
+	if err != nil {
+		r.msgs <- errorMsg{err: err}
+	} else {
+		r.msgs <- reposUpdatedMsg(repos)
+	}
+	r.msgs <- refreshFinishedMsg{}
+}
 
 type Action int
 
@@ -33,6 +56,9 @@ const (
 	ActionCopy
 	ActionBrowse
 	ActionPRs
+	ActionTmuxSession
+	ActionTmuxWindow
+	ActionCustom // User-defined command from the config, see CustomCommand
 	ActionQuit
 )
 
@@ -45,6 +71,9 @@ const (
 	cfgShowCollaborator
 	cfgShowOrgMember
 	cfgShowLocal
+	cfgAutoSync
+	cfgRemoteInterval
+	cfgLocalInterval
 	cfgFieldCount
 )
 
@@ -56,9 +85,18 @@ type Model struct {
 	usage   UsageData
 	cursor  int
 
+	sortMode index.SortMode
+
+	// Session filter, starts from config and is toggled from the filter overlay
+	filter RepoFilter
+
 	message    StatusMessage
+	messages   messageLog
 	refreshing bool
 
+	// Git working tree state of local clones, keyed by local path
+	localStatus map[string]LocalStatus
+
 	width  int
 	height int
 
@@ -66,6 +104,7 @@ type Model struct {
 	selectedRepo   *Repository
 	selectedAction Action
 	selectedPath   string
+	selectedCustom *CustomCommand
 
 	refreshChan chan<- struct{}
 
@@ -79,16 +118,56 @@ type Model struct {
 	showCommands  bool
 	commandCursor int
 
+	showFilters  bool
+	filterCursor int
+
+	showHelp   bool
+	helpQuery  string
+	helpScroll int
+
+	showLog   bool
+	logScroll int
+
 	// Cache file watching
 	cacheMtime time.Time
 
+	// Sync state shown in the status bar, refreshed on every cache check tick
+	meta        CacheMetadata
+	syncRunning bool
+	lastSyncErr string
+
+	// Detached sync started by this UI (0 if none) and the message
+	// showing its progress
+	bgSyncPID   int
+	bgSyncMsgID int
+
 	// First run state
 	firstRun bool
+
+	// Clone running inside the UI, nil when idle
+	clone *cloneJob
+
+	// Clone rule editor, nil when closed
+	rules *ruleEditor
+
+	// Failed hooks of in-UI clones, reported after the UI exits
+	hookErrs []error
 }
 
 // setMessage sets the status message with the given level
 func (m *Model) setMessage(text string, level MessageLevel) {
-	m.message = StatusMessage{Text: text, Level: level}
+	m.setMessageWithHint(text, level, "")
+}
+
+// setMessageWithHint sets the status message along with a suggested action.
+// Every message is recorded in the message log. A pinned error stays on
+// screen until acknowledged, so later non-error messages only go to the log.
+func (m *Model) setMessageWithHint(text string, level MessageLevel, hint string) {
+	msg := m.messages.add(StatusMessage{Text: text, Level: level, Hint: hint})
+	if m.message.IsPinned() && level != ErrorLevel {
+		return
+	}
+	m.message = msg
 }
 
 // clearMessage clears the status message
@@ -96,10 +175,12 @@ func (m *Model) clearMessage() {
 	m.message = StatusMessage{}
 }
 
-// clearMessageAfter returns a command that clears the message after a delay
-func clearMessageAfter(d time.Duration) tea.Cmd {
+// clearMessageAfter returns a command that clears the current message after a
+// delay, unless it has been replaced or is a pinned error by then
+func (m *Model) clearMessageAfter(d time.Duration) tea.Cmd {
+	id := m.message.ID
 	return tea.Tick(d, func(t time.Time) tea.Msg {
-		return clearMessageMsg{}
+		return clearMessageMsg{id: id}
 	})
 }
 
@@ -108,13 +189,15 @@ type command struct {
 	name   string
 	action Action
 	fn     func(*Model)
+	custom *CustomCommand
 }
 
 func newModel(cache []Repository, config Config, refreshChan chan<- struct{}, cacheMtime time.Time, firstRun bool) Model {
 	usage, _ := LoadUsage()
+	meta, _ := LoadMetadata()
 
 	// Apply filter to get display repos
-	filtered := filterRepos(cache, config)
+	filtered := filterRepos(cache, config.Filter())
 
 	m := Model{
 		cache:       cache,
@@ -122,10 +205,18 @@ func newModel(cache []Repository, config Config, refreshChan chan<- struct{}, ca
 		query:       "",
 		config:      config,
 		usage:       usage,
+		sortMode:    index.ParseSortMode(meta.SortMode),
+		filter:      config.Filter(),
 		refreshChan: refreshChan,
 		inputs:      make([]textinput.Model, cfgFieldCount),
 		cacheMtime:  cacheMtime,
 		firstRun:    firstRun,
+		localStatus: make(map[string]LocalStatus),
+		meta:        meta,
+		syncRunning: isSyncRunning(),
+	}
+	for _, warning := range shadowedCommandWarnings(config.Commands) {
+		m.messages.add(StatusMessage{Text: warning, Level: WarningLevel})
 	}
 
 	for i := 0; i < cfgFieldCount; i++ {
@@ -160,6 +251,9 @@ func newModel(cache []Repository, config Config, refreshChan chan<- struct{}, ca
 	m.inputs[cfgShowCollaborator].Placeholder = "yes"
 	m.inputs[cfgShowOrgMember].Placeholder = "yes"
 	m.inputs[cfgShowLocal].Placeholder = "yes"
+	m.inputs[cfgAutoSync].Placeholder = "yes"
+	m.inputs[cfgRemoteInterval].Placeholder = formatInterval(DefaultRemoteSyncInterval)
+	m.inputs[cfgLocalInterval].Placeholder = formatInterval(DefaultLocalScanInterval)
 
 	// Shorter width for boolean fields
 	m.inputs[cfgUseCloneRules].Width = 5
@@ -167,8 +261,18 @@ func newModel(cache []Repository, config Config, refreshChan chan<- struct{}, ca
 	m.inputs[cfgShowCollaborator].Width = 5
 	m.inputs[cfgShowOrgMember].Width = 5
 	m.inputs[cfgShowLocal].Width = 5
+	m.inputs[cfgAutoSync].Width = 5
+	m.inputs[cfgRemoteInterval].Width = 10
+	m.inputs[cfgLocalInterval].Width = 10
 
 	m.applySearch()
+
+	// The index may be stale, say why
+	if meta.LastError != "" {
+		m.lastSyncErr = meta.LastError
+		m.setMessageWithHint(fmt.Sprintf("last sync failed %s: %s", syncAge(meta.LastErrorAt), meta.LastError),
+			WarningLevel, "press space r to retry, see sync.log")
+	}
 	return m
 }
 
@@ -182,14 +286,16 @@ func tickCacheCheck() tea.Cmd {
 	})
 }
 
-// openConfigInEditor opens the config file in the user's $EDITOR
-func openConfigInEditor() tea.Cmd {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vi" // fallback
+// openConfigInEditor opens the config file in the configured editor or $EDITOR
+func openConfigInEditor(config Config) tea.Cmd {
+	argv := config.Editor
+	if len(argv) == 0 {
+		argv = strings.Fields(os.Getenv("EDITOR"))
+	}
+	if len(argv) == 0 {
+		argv = []string{"vi"} // fallback
 	}
-	configPath := xdgConfigPath()
-	c := exec.Command(editor, configPath)
+	c := editorCommand(argv, xdgConfigPath(), Repository{})
 	return tea.ExecProcess(c, func(err error) tea.Msg {
 		return configEditedMsg{}
 	})
@@ -197,7 +303,15 @@ func openConfigInEditor() tea.Cmd {
 
 // Init starts the cache file watcher ticker
 func (m Model) Init() tea.Cmd {
-	return tickCacheCheck()
+	return tea.Batch(tickCacheCheck(), m.refreshLocalStatus())
+}
+
+// refreshLocalStatus reloads git status for local repos when a column shows it
+func (m Model) refreshLocalStatus() tea.Cmd {
+	if !columnsNeedLocalStatus(m.config.GetColumns()) {
+		return nil
+	}
+	return loadLocalStatusCmd(m.cache)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -208,32 +322,71 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case clearMessageMsg:
-		m.clearMessage()
+		if msg.id == m.message.ID && !m.message.IsPinned() {
+			m.clearMessage()
+		}
+		return m, nil
+
+	case localStatusMsg:
+		for path, status := range msg {
+			m.localStatus[path] = status
+		}
 		return m, nil
 
+	case customDoneMsg:
+		return m.customDone(msg)
+
 	case cacheCheckTickMsg:
+		m.refreshSyncState()
+		m.followBackgroundSync()
+
 		// Check if cache file has been updated by external process
 		currentMtime := GetCacheMtime()
 		if !currentMtime.IsZero() && currentMtime.After(m.cacheMtime) {
 			// Cache file was updated, reload it
 			if repos, err := loadRepoCache(); err == nil && len(repos) > 0 {
 				m.cache = repos
-				m.all = filterRepos(repos, m.config)
+				m.all = filterRepos(repos, m.filter)
 				m.applySearch()
 				if m.cursor >= len(m.results) {
 					m.cursor = max(0, len(m.results)-1)
 				}
 				m.cacheMtime = currentMtime
 				m.refreshing = false // Clear refreshing state since sync completed
+				// A local scan keeps the error of a failed remote sync
+				m.lastSyncErr = m.meta.LastError
 				m.setMessage(fmt.Sprintf("%d repos loaded", len(m.all)), InfoLevel)
 				// Clear message after 5 seconds
-				return m, tea.Batch(tickCacheCheck(), clearMessageAfter(5*time.Second))
+				return m, tea.Batch(tickCacheCheck(), m.clearMessageAfter(5*time.Second), m.refreshLocalStatus())
 			}
 		}
 		// Always schedule next tick
 		return m, tickCacheCheck()
 	}
 
+	if m.clone != nil {
+		switch msg.(type) {
+		case tea.KeyMsg, cloneProgressMsg, cloneDoneMsg, hooksDoneMsg:
+			return m.updateClone(msg)
+		}
+	}
+
+	if key, ok := msg.(tea.KeyMsg); ok {
+		if m.showHelp {
+			return m.updateHelp(key)
+		}
+		if m.showLog {
+			return m.updateMessageLog(key)
+		}
+		if key.Type == tea.KeyF1 {
+			m.openHelp()
+			return m, nil
+		}
+		if m.rules != nil {
+			return m.updateRuleEditor(key)
+		}
+	}
+
 	if m.showConfig {
 		return m.updateConfig(msg)
 	}
@@ -253,78 +406,26 @@ func (m Model) View() string {
 func (m Model) updateConfig(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyEsc:
-			m.showConfig = false
-			return m, nil
-
-		case tea.KeyEnter:
-			changes, err := m.saveConfigFromInputs()
-			if err != nil {
-				m.setMessage(fmt.Sprintf("config error: %v", err), ErrorLevel)
-			} else {
-				// Always reapply filters after config save
-				m.all = filterRepos(m.cache, m.config)
-				m.applySearch()
-				if m.cursor >= len(m.results) {
-					m.cursor = max(0, len(m.results)-1)
-				}
-				m.setMessage(fmt.Sprintf("config saved, %d repos", len(m.all)), InfoLevel)
-
-				// If repo_roots changed, trigger local scan to update repos
-				if changes.repoRootsChanged {
-					m.setMessage("config saved, scanning local repos...", InfoLevel)
-					if updated, err := runLocalScan(m.config, m.cache); err == nil {
-						m.cache = updated
-						m.all = filterRepos(m.cache, m.config)
-						m.applySearch()
-						m.cacheMtime = GetCacheMtime()
-						m.setMessage(fmt.Sprintf("config saved, %d repos", len(m.all)), InfoLevel)
-					}
-				}
-
-				// On first run, spawn background sync to fetch remote repos
-				if m.firstRun {
-					m.firstRun = false
-					if !isSyncRunning() {
-						if spawnDetachedSync() {
-							m.setMessage("Config saved, syncing repositories...", InfoLevel)
-							m.refreshing = true
-						}
-					}
-				}
-			}
-			m.showConfig = false
-			return m, nil
-
-		case tea.KeyTab, tea.KeyDown:
-			m.inputs[m.configFocus].Blur()
-			m.configFocus = (m.configFocus + 1) % cfgFieldCount
-			m.inputs[m.configFocus].Focus()
-			return m, nil
-
-		case tea.KeyShiftTab, tea.KeyUp:
-			m.inputs[m.configFocus].Blur()
-			m.configFocus = (m.configFocus - 1 + cfgFieldCount) % cfgFieldCount
-			m.inputs[m.configFocus].Focus()
-			return m, nil
-
-		case tea.KeySpace:
-			return m, openConfigInEditor()
+		if model, cmd, ok := dispatchKey(configKeys(), m, msg); ok {
+			return model, cmd
 		}
 	case configEditedMsg:
 		// Reload config after external edit and close overlay
 		if cfg, err := LoadConfig(); err == nil {
 			m.config = cfg
+			m.filter = cfg.Filter()
 			m.loadConfigIntoInputs()
-			m.all = filterRepos(m.cache, m.config)
+			m.all = filterRepos(m.cache, m.filter)
 			m.applySearch()
 			m.setMessage("config reloaded", InfoLevel)
+			for _, warning := range shadowedCommandWarnings(cfg.Commands) {
+				m.messages.add(StatusMessage{Text: warning, Level: WarningLevel})
+			}
 		} else {
-			m.setMessage(fmt.Sprintf("config reload error: %v", err), ErrorLevel)
+			m.setMessageWithHint(fmt.Sprintf("config reload error: %v", err), ErrorLevel, "press space c to fix")
 		}
 		m.showConfig = false // Close config overlay after external edit
-		return m, nil
+		return m, m.refreshLocalStatus()
 	}
 
 	var cmd tea.Cmd
@@ -332,6 +433,54 @@ func (m Model) updateConfig(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+// focusConfigField moves the config overlay focus to field
+func (m *Model) focusConfigField(field int) {
+	m.inputs[m.configFocus].Blur()
+	m.configFocus = field
+	m.inputs[m.configFocus].Focus()
+}
+
+// saveConfig saves the config overlay inputs and closes the overlay
+func (m Model) saveConfig() (tea.Model, tea.Cmd) {
+	changes, err := m.saveConfigFromInputs()
+	if err != nil {
+		m.setMessageWithHint(fmt.Sprintf("config error: %v", err), ErrorLevel, "press space c to fix")
+	} else {
+		// Always reapply filters after config save
+		m.filter = m.config.Filter()
+		m.all = filterRepos(m.cache, m.filter)
+		m.applySearch()
+		if m.cursor >= len(m.results) {
+			m.cursor = max(0, len(m.results)-1)
+		}
+		m.setMessage(fmt.Sprintf("config saved, %d repos", len(m.all)), InfoLevel)
+
+		// If repo_roots changed, trigger local scan to update repos
+		if changes.repoRootsChanged {
+			m.setMessage("config saved, scanning local repos...", InfoLevel)
+			if updated, err := runSync(context.Background(), m.config, false, nopReporter{}); err == nil {
+				m.cache = updated
+				m.all = filterRepos(m.cache, m.filter)
+				m.applySearch()
+				m.cacheMtime = GetCacheMtime()
+				m.setMessage(fmt.Sprintf("config saved, %d repos", len(m.all)), InfoLevel)
+			}
+		}
+
+		// On first run, spawn background sync to fetch remote repos
+		if m.firstRun {
+			m.firstRun = false
+			if !isSyncRunning() {
+				if pid := spawnDetachedSync(); pid != 0 {
+					m.watchBackgroundSync(pid, "Config saved, syncing repositories...")
+				}
+			}
+		}
+	}
+	m.showConfig = false
+	return m, nil
+}
+
 func (m Model) updateMain(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
@@ -340,35 +489,39 @@ func (m Model) updateMain(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.setMessage("refreshing...", InfoLevel)
 		return m, nil
 
-	case localRefreshDoneMsg:
-		m.setMessage("Locals updated. Syncing remotes…", InfoLevel)
+	case syncProgressMsg:
+		if m.refreshing {
+			m.setMessage(string(msg)+"...", InfoLevel)
+		}
 		return m, nil
 
 	case localReposUpdatedMsg:
 		m.cache = []Repository(msg)
-		m.all = filterRepos(m.cache, m.config)
+		m.all = filterRepos(m.cache, m.filter)
 		m.applySearch()
 
 		if m.cursor >= len(m.results) {
 			m.cursor = max(0, len(m.results)-1)
 		}
-		return m, nil
+		return m, m.refreshLocalStatus()
 
 	case refreshFinishedMsg:
 		m.refreshing = false
-		if m.message.Text == "refreshing..." || strings.HasPrefix(m.message.Text, "Local refresh done") {
+		if m.lastSyncErr == "" {
 			m.setMessage("Sync complete", InfoLevel)
-			return m, clearMessageAfter(5 * time.Second)
+			return m, m.clearMessageAfter(5 * time.Second)
 		}
 		return m, nil
 
 	case errorMsg:
-		m.setMessage(msg.err.Error(), ErrorLevel)
+		m.setMessageWithHint(msg.err.Error(), ErrorLevel, "press space r to retry")
+		m.lastSyncErr = msg.err.Error()
 		return m, nil
 
 	case reposUpdatedMsg:
 		m.cache = []Repository(msg)
-		m.all = filterRepos(m.cache, m.config)
+		m.lastSyncErr = ""
+		m.all = filterRepos(m.cache, m.filter)
 		m.applySearch()
 
 		if m.cursor >= len(m.results) {
@@ -378,73 +531,28 @@ func (m Model) updateMain(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.setMessage(fmt.Sprintf("%d repos loaded", len(m.all)), InfoLevel)
 			// Update our tracked mtime since we just got new data
 			m.cacheMtime = GetCacheMtime()
-			return m, clearMessageAfter(5 * time.Second)
+			return m, tea.Batch(m.clearMessageAfter(5*time.Second), m.refreshLocalStatus())
 		}
 		m.cacheMtime = GetCacheMtime()
-		return m, nil
+		return m, m.refreshLocalStatus()
 
 	case tea.KeyMsg:
 		if m.showCommands {
 			return m.updateCommands(msg)
 		}
+		if m.showFilters {
+			return m.updateFilters(msg)
+		}
 
-		switch msg.Type {
-
-		case tea.KeyCtrlC:
-			return m, tea.Quit
-
-		case tea.KeyEsc:
-			if m.query != "" {
-				m.query = ""
-				m.applySearch()
-				return m, nil
-			}
-			return m, tea.Quit
-
-		case tea.KeyUp:
-			if m.cursor > 0 {
-				m.cursor--
-			}
-			return m, nil
-
-		case tea.KeyDown:
-			if m.cursor < len(m.results)-1 {
-				m.cursor++
-			}
-			return m, nil
-
-		case tea.KeyEnter:
-			if len(m.results) == 0 {
-				return m, nil
-			}
-			r := m.results[m.cursor]
-			m.selectedRepo = &r
-			m.selectedAction = ActionOpen
-			return m, tea.Quit
-
-		case tea.KeyBackspace:
-			if len(m.query) > 0 {
-				m.query = m.query[:len(m.query)-1]
-				m.applySearch()
-			}
-			return m, nil
+		if model, cmd, ok := dispatchKey(mainKeys(), m, msg); ok {
+			return model, cmd
+		}
 
-		case tea.KeySpace:
-			m.showCommands = true
-			m.commandCursor = 0
+		// Anything else typed goes into the search query
+		if msg.Type == tea.KeyRunes {
+			m.query += msg.String()
+			m.applySearch()
 			return m, nil
-		default:
-			if msg.Type == tea.KeyRunes {
-				r := msg.String()
-				if r == " " {
-					m.showCommands = true
-					m.commandCursor = 0
-					return m, nil
-				}
-				m.query += r
-				m.applySearch()
-				return m, nil
-			}
 		}
 	}
 
@@ -452,20 +560,8 @@ func (m Model) updateMain(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m Model) updateManualPath(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	switch msg.Type {
-	case tea.KeyEsc:
-		m.showManualPath = false
-		return m, nil
-
-	case tea.KeyEnter:
-		path := strings.TrimSpace(m.manualPathInput.Value())
-		m.showManualPath = false
-		if path == "" {
-			return m, nil
-		}
-		m.selectedPath = path
-		m.selectedAction = ActionOpenPath
-		return m, tea.Quit
+	if model, cmd, ok := dispatchKey(manualPathKeys(), m, msg); ok {
+		return model, cmd
 	}
 
 	var cmd tea.Cmd
@@ -494,47 +590,21 @@ func (m Model) updateCommands(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyEnter:
-		cmd := cmds[m.commandCursor]
 		m.showCommands = false
-		if cmd.action == ActionQuit {
-			return m, tea.Quit
-		}
-		if cmd.action != ActionNone {
-			if len(m.results) > 0 {
-				r := m.results[m.cursor]
-				m.selectedRepo = &r
-				m.selectedAction = cmd.action
-				return m, tea.Quit
-			}
-			return m, nil
-		}
-		if cmd.fn != nil {
-			cmd.fn(&m)
-		}
-		return m, nil
+		return m.runCommand(cmds[m.commandCursor])
 
 	default:
 		if msg.Type == tea.KeyRunes {
 			key := msg.String()
+			if key == "?" {
+				m.showCommands = false
+				m.openHelp()
+				return m, nil
+			}
 			for _, cmd := range cmds {
 				if cmd.key == key {
 					m.showCommands = false
-					if cmd.action == ActionQuit {
-						return m, tea.Quit
-					}
-					if cmd.action != ActionNone {
-						if len(m.results) > 0 {
-							r := m.results[m.cursor]
-							m.selectedRepo = &r
-							m.selectedAction = cmd.action
-							return m, tea.Quit
-						}
-						return m, nil
-					}
-					if cmd.fn != nil {
-						cmd.fn(&m)
-					}
-					return m, nil
+					return m.runCommand(cmd)
 				}
 			}
 		}
@@ -543,6 +613,21 @@ func (m Model) updateCommands(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// runCommand runs a palette entry
+func (m Model) runCommand(cmd command) (tea.Model, tea.Cmd) {
+	switch {
+	case cmd.action == ActionQuit:
+		return m, tea.Quit
+	case cmd.custom != nil:
+		return m.runCustom(*cmd.custom)
+	case cmd.action != ActionNone:
+		return m.chooseRepo(cmd.action)
+	case cmd.fn != nil:
+		cmd.fn(&m)
+	}
+	return m, nil
+}
+
 func (m Model) viewMain() string {
 	// Use sensible defaults if window size not yet received
 	width := m.width
@@ -556,22 +641,14 @@ func (m Model) viewMain() string {
 
 	var b strings.Builder
 
-	localW := 6
-	separators := 4
-	ownerW := 20
-	nameW := 35
+	layout := layoutColumns(m.config.GetColumns(), width)
 
-	if width > 0 {
-		ownerW = clamp(width/4, 10, 30)
-		nameW = width - ownerW - localW - separators
-		nameW = max(15, nameW)
+	header := renderHeader(layout)
+	sortLabel := dimStyle.Render("sort: " + string(m.sortMode))
+	if labelW := lipgloss.Width(sortLabel); width > labelW+1 {
+		header = ansi.Truncate(header, width-labelW-1, "")
+		header = padLineToWidth(header, width-labelW, bgOnlyStyle) + sortLabel
 	}
-
-	sep := bgOnlyStyle.Render("  ")
-
-	header := headerStyle.Render(padOrTrim("REPO", nameW)) + sep +
-		headerStyle.Render(padOrTrim("LOCAL", localW)) + sep +
-		headerStyle.Render(padOrTrim("OWNER", ownerW))
 	b.WriteString(padLineToWidth(header, width, bgOnlyStyle))
 	b.WriteString("\n")
 
@@ -582,6 +659,7 @@ func (m Model) viewMain() string {
 		if !m.message.IsEmpty() {
 			reserved += 3 // empty line + message + empty line
 		}
+		reserved++ // status bar
 		maxRows = max(5, height-reserved)
 	}
 
@@ -604,39 +682,18 @@ func (m Model) viewMain() string {
 		b.WriteString("\n")
 	}
 
-	overlayOpen := m.showCommands || m.showConfig || m.showManualPath
+	overlayOpen := m.showCommands || m.showConfig || m.showManualPath || m.showFilters || m.showHelp || m.showLog || m.clone != nil || m.rules != nil
 
 	if total == 0 {
 		b.WriteString(padLineToWidth(dimStyle.Render("no matches"), width, bgOnlyStyle))
 		b.WriteString("\n")
 	} else {
 		for i := start; i < end; i++ {
-			r := m.results[i]
-
-			localText := "remote"
-			localStyled := localNoStyle.Render(padOrTrim(localText, localW))
-			if r.ExistsLocal {
-				localText = "local"
-				localStyled = localYesStyle.Render(padOrTrim(localText, localW))
-			}
-
-			var line string
 			if i == m.cursor && !overlayOpen {
-				cursorSep := cursorSepStyle.Render("  ")
-				namePart := cursorStyle.Render(padOrTrim(r.Name, nameW))
-				ownerPart := cursorStyle.Render(padOrTrim(r.Owner, ownerW))
-
-				localPart := localNoCursorStyle.Render(padOrTrim(localText, localW))
-				if r.ExistsLocal {
-					localPart = localYesCursorStyle.Render(padOrTrim(localText, localW))
-				}
-
-				line = namePart + cursorSep + localPart + cursorSep + ownerPart
+				line := m.renderRow(layout, m.results[i], true)
 				b.WriteString(padLineToWidth(line, width, cursorSepStyle))
 			} else {
-				namePart := repoNameStyle.Render(padOrTrim(r.Name, nameW))
-				ownerPart := ownerStyle.Render(padOrTrim(r.Owner, ownerW))
-				line = namePart + sep + localStyled + sep + ownerPart
+				line := m.renderRow(layout, m.results[i], false)
 				b.WriteString(padLineToWidth(line, width, bgOnlyStyle))
 			}
 			b.WriteString("\n")
@@ -653,12 +710,15 @@ func (m Model) viewMain() string {
 		b.WriteString("\n")
 	}
 
+	b.WriteString(m.renderStatusBar(width))
+	b.WriteString("\n")
+
 	searchLeft := promptStyle.Render("> ") + queryStyle.Render(m.query)
 	if m.query == "" {
 		searchLeft += inputTextStyle.Render("type to search")
 	}
 
-	hints := keybindStyle.Render("space commands  enter open  ")
+	hints := keybindStyle.Render("space commands  tab sort  ? help  enter open  ")
 	searchLeftWidth := lipgloss.Width(searchLeft)
 	hintsWidth := lipgloss.Width(hints)
 	padding := width - searchLeftWidth - hintsWidth
@@ -671,6 +731,18 @@ func (m Model) viewMain() string {
 	mainContent := b.String()
 	mainRendered := lipgloss.Place(width, height, lipgloss.Left, lipgloss.Bottom, mainContent, lipgloss.WithWhitespaceBackground(bgColor))
 
+	if m.clone != nil {
+		return m.overlayCenter(mainRendered, m.buildCloneBox())
+	}
+	if m.showHelp {
+		return m.overlayCenter(mainRendered, m.buildHelpBox())
+	}
+	if m.showLog {
+		return m.overlayCenter(mainRendered, m.buildMessageLogBox())
+	}
+	if m.rules != nil {
+		return m.overlayCenter(mainRendered, m.buildRuleEditorBox())
+	}
 	if m.showConfig {
 		configContent := m.buildConfigBox()
 		return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, configContent, lipgloss.WithWhitespaceBackground(bgColor))
@@ -681,6 +753,9 @@ func (m Model) viewMain() string {
 	if m.showCommands {
 		return m.overlayCenter(mainRendered, m.buildCommandBox())
 	}
+	if m.showFilters {
+		return m.overlayCenter(mainRendered, m.buildFilterBox())
+	}
 
 	return mainRendered
 }
@@ -719,7 +794,7 @@ func (m Model) buildCommandBox() string {
 	}
 
 	lines = append(lines, "")
-	lines = append(lines, keybindStyle.Render("↑↓ navigate  enter select  esc close"))
+	lines = append(lines, keybindStyle.Render("↑↓ navigate  enter select  ? help  esc close"))
 
 	return overlayStyle.Render(strings.Join(lines, "\n"))
 }
@@ -753,6 +828,13 @@ func (m Model) buildConfigBox() string {
 		"Show Local Only",
 	}
 
+	// Sync schedule labels
+	syncLabels := []string{
+		"Auto Sync",
+		"GitHub Interval",
+		"Local Interval",
+	}
+
 	// Calculate the width of the config content (label + input)
 	// Label is 20 chars, input is 50 chars wide
 	contentWidth := 20 + 50
@@ -786,6 +868,16 @@ func (m Model) buildConfigBox() string {
 		lines = append(lines, line)
 	}
 
+	lines = append(lines, "")
+	lines = append(lines, dimStyle.Render("Sync:"))
+
+	// Sync schedule fields
+	for i, label := range syncLabels {
+		fieldIdx := cfgAutoSync + i
+		line := configLabelStyle.Render(fmt.Sprintf("%-20s", label)) + m.inputs[fieldIdx].View()
+		lines = append(lines, line)
+	}
+
 	lines = append(lines, "")
 
 	// Show description for focused field using the info box style
@@ -799,7 +891,7 @@ func (m Model) buildConfigBox() string {
 	}
 
 	lines = append(lines, "")
-	lines = append(lines, keybindStyle.Render("tab/↑↓ navigate   enter save   space edit file   esc close"))
+	lines = append(lines, keybindStyle.Render("tab/↑↓ navigate   enter save   ctrl+r rules   space edit file   f1 help   esc close"))
 
 	return overlayStyle.Render(strings.Join(lines, "\n"))
 }
@@ -856,50 +948,18 @@ func (m Model) overlayCenter(base, overlay string) string {
 }
 
 func (m *Model) applySearch() {
-	q := strings.TrimSpace(m.query)
-	if q == "" {
-		m.results = SortByUsage(m.all, m.usage)
-		m.cursor = max(0, len(m.results)-1)
-		return
-	}
-
-	haystack := make([]string, 0, len(m.all))
-	for _, r := range m.all {
-		haystack = append(haystack, r.SearchText)
-	}
-
-	matches := fuzzy.Find(q, haystack)
-
-	type scoredRepo struct {
-		repo       Repository
-		fuzzyScore int
-		usageBoost float64
-		combined   float64
-	}
+	m.results = searchRepos(m.all, m.query, m.usage, m.sortMode)
+	m.cursor = max(0, len(m.results)-1)
+}
 
-	scored := make([]scoredRepo, 0, len(matches))
-	for _, mt := range matches {
-		repo := m.all[mt.Index]
-		usageBoost := GetUsageBoost(m.usage, repo)
-		combined := float64(mt.Score) + usageBoost*50
-		scored = append(scored, scoredRepo{
-			repo:       repo,
-			fuzzyScore: mt.Score,
-			usageBoost: usageBoost,
-			combined:   combined,
-		})
-	}
+// cycleSortMode switches to the next sort mode and remembers it in the metadata file
+func (m *Model) cycleSortMode() {
+	m.sortMode = m.sortMode.Next()
+	m.applySearch()
 
-	sort.SliceStable(scored, func(i, j int) bool {
-		return scored[i].combined < scored[j].combined
+	_ = UpdateMetadata(func(meta *CacheMetadata) {
+		meta.SortMode = string(m.sortMode)
 	})
-
-	m.results = make([]Repository, 0, len(scored))
-	for _, s := range scored {
-		m.results = append(m.results, s.repo)
-	}
-
-	m.cursor = max(0, len(m.results)-1)
 }
 
 func (m *Model) openManualPathPrompt() {
@@ -917,6 +977,23 @@ func (m *Model) loadConfigIntoInputs() {
 	m.inputs[cfgShowCollaborator].SetValue(boolToYesNo(m.config.ShowCollaborator))
 	m.inputs[cfgShowOrgMember].SetValue(boolToYesNo(m.config.ShowOrgMember))
 	m.inputs[cfgShowLocal].SetValue(boolToYesNo(m.config.ShowLocal))
+	m.inputs[cfgAutoSync].SetValue(boolToYesNo(m.config.AutoSync()))
+	m.inputs[cfgRemoteInterval].SetValue(formatInterval(m.config.Sync.RemoteInterval))
+	m.inputs[cfgLocalInterval].SetValue(formatInterval(m.config.Sync.LocalInterval))
+}
+
+// parseInterval parses a sync interval of the config overlay, empty meaning
+// the default; field is the config key used in errors
+func parseInterval(field, s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration like 12h or 30m (got %q)", field, s)
+	}
+	return d, nil
 }
 
 // boolToYesNo converts a bool to "yes" or "no"
@@ -963,19 +1040,30 @@ func (m *Model) saveConfigFromInputs() (changes configChanges, err error) {
 		showOrgMember != m.config.ShowOrgMember ||
 		showLocal != m.config.ShowLocal
 
-	cfg := Config{
-		RepoRoots:     repoRoots,
-		CloneRoot:     m.inputs[cfgCloneRoot].Value(),
-		UseCloneRules: yesNoToBool(m.inputs[cfgUseCloneRules].Value()),
-		CloneRules:    m.config.CloneRules, // Preserve existing clone rules (edited via config file)
-		GitHub: GitHubConfig{
-			Affiliation: "owner,collaborator,organization_member", // Always fetch all
-			Orgs:        m.inputs[cfgOrgs].Value(),
-		},
-		ShowOwner:        showOwner,
-		ShowCollaborator: showCollaborator,
-		ShowOrgMember:    showOrgMember,
-		ShowLocal:        showLocal,
+	// Start from the current config so settings only editable in the
+	// config file (clone rules, columns) are preserved
+	cfg := m.config
+	cfg.RepoRoots = repoRoots
+	cfg.CloneRoot = m.inputs[cfgCloneRoot].Value()
+	cfg.UseCloneRules = yesNoToBool(m.inputs[cfgUseCloneRules].Value())
+	cfg.GitHub = GitHubConfig{
+		Affiliation: "owner,collaborator,organization_member", // Always fetch all
+		Orgs:        m.inputs[cfgOrgs].Value(),
+	}
+	cfg.ShowOwner = showOwner
+	cfg.ShowCollaborator = showCollaborator
+	cfg.ShowOrgMember = showOrgMember
+	cfg.ShowLocal = showLocal
+
+	// Keep auto unset while it has its default, so the file stays minimal
+	if autoSync := yesNoToBool(m.inputs[cfgAutoSync].Value()); autoSync != cfg.AutoSync() {
+		cfg.Sync.Auto = &autoSync
+	}
+	if cfg.Sync.RemoteInterval, err = parseInterval("sync.remote_interval", m.inputs[cfgRemoteInterval].Value()); err != nil {
+		return configChanges{}, err
+	}
+	if cfg.Sync.LocalInterval, err = parseInterval("sync.local_interval", m.inputs[cfgLocalInterval].Value()); err != nil {
+		return configChanges{}, err
 	}
 
 	if err := cfg.Validate(); err != nil {
@@ -1003,14 +1091,43 @@ func stringSlicesEqual(a, b []string) bool {
 	return true
 }
 
+// getCommands returns the palette entries: the built-in commands followed by
+// the custom commands from the config. A custom command hides the built-in
+// with its key, see shadowedCommandWarnings.
 func (m *Model) getCommands() []command {
+	var cmds []command
+	for _, cmd := range m.builtinCommands() {
+		if !slices.ContainsFunc(m.config.Commands, func(c CustomCommand) bool { return c.Key == cmd.key }) {
+			cmds = append(cmds, cmd)
+		}
+	}
+	for i := range m.config.Commands {
+		c := &m.config.Commands[i]
+		cmds = append(cmds, command{key: c.Key, name: c.Name, custom: c})
+	}
+	return cmds
+}
+
+func (m *Model) builtinCommands() []command {
 	return []command{
 		{key: "o", name: "enter path", fn: func(m *Model) {
 			m.openManualPathPrompt()
 		}},
 		{key: "y", name: "copy path", action: ActionCopy},
+		{key: "m", name: "open in tmux session", action: ActionTmuxSession},
+		{key: "w", name: "open in tmux window", action: ActionTmuxWindow},
 		{key: "b", name: "open in browser", action: ActionBrowse},
 		{key: "p", name: "open pull requests", action: ActionPRs},
+		{key: "f", name: "filters", fn: func(m *Model) {
+			m.showFilters = true
+			m.filterCursor = 0
+		}},
+		{key: "s", name: "cycle sort mode", fn: func(m *Model) {
+			m.cycleSortMode()
+		}},
+		{key: "t", name: "test clone rules", fn: func(m *Model) {
+			m.openRuleEditor()
+		}},
 		{key: "r", name: "refresh", fn: func(m *Model) {
 			if !m.refreshing {
 				m.refreshing = true
@@ -1021,6 +1138,9 @@ func (m *Model) getCommands() []command {
 				}
 			}
 		}},
+		{key: "l", name: "message log", fn: func(m *Model) {
+			m.openMessageLog()
+		}},
 		{key: "c", name: "config", fn: func(m *Model) {
 			m.showConfig = true
 			m.configFocus = 0
@@ -1034,13 +1154,12 @@ func (m *Model) getCommands() []command {
 	}
 }
 
-func ui(initial []Repository, config Config, uiMsgs <-chan tea.Msg, refreshChan chan<- struct{}, cacheMtime time.Time, syncInProgress bool, firstRun bool) (*Repository, Action, string, Config) {
+func ui(initial []Repository, config Config, uiMsgs <-chan tea.Msg, refreshChan chan<- struct{}, cacheMtime time.Time, syncPID int, firstRun bool) (*Repository, Action, string, *CustomCommand, Config) {
 	model := newModel(initial, config, refreshChan, cacheMtime, firstRun)
 
 	// Set initial status if background sync was spawned
-	if syncInProgress {
-		model.setMessage("Syncing repositories in background...", InfoLevel)
-		model.refreshing = true
+	if syncPID != 0 {
+		model.watchBackgroundSync(syncPID, "Syncing repositories in background...")
 	}
 
 	// On first run, auto-open config overlay so user can set up
@@ -1070,5 +1189,8 @@ func ui(initial []Repository, config Config, uiMsgs <-chan tea.Msg, refreshChan
 	}
 
 	m := finalModel.(Model)
-	return m.selectedRepo, m.selectedAction, m.selectedPath, m.config
+	for _, err := range m.hookErrs {
+		reportHookError(err)
+	}
+	return m.selectedRepo, m.selectedAction, m.selectedPath, m.selectedCustom, m.config
 }